@@ -0,0 +1,289 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+	"order-system/pkg/infra/server"
+	"order-system/pkg/platform/logger"
+)
+
+// redacted replaces secrets in the effective configuration
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are substrings of configuration keys holding secrets
+var sensitiveKeys = []string{"password", "secret", "token", "credential", "privatekey"}
+
+// handler serves the admin endpoints
+type handler struct {
+	config *config.Config
+	log    logger.Logger
+	levels logger.LevelController
+	opts   Options
+	binder *server.Binder
+}
+
+// New creates the admin server listening on config.Admin.Port. The admin
+// server has no write timeout, as CPU profiles and execution traces stream
+// for as long as the caller asks.
+func New(cfg *config.Config, log logger.Logger, opts Options) (*server.Server, error) {
+	if !cfg.Admin.Enabled {
+		return nil, fmt.Errorf("admin server is disabled")
+	}
+
+	h, err := NewHandler(cfg, log, opts)
+	if err != nil {
+		return nil, err
+	}
+	return server.New(cfg, h,
+		server.WithListener(server.NewTCPListener(fmt.Sprintf(":%d", cfg.Admin.Port))),
+		server.WithLogger(log.WithComponent("admin")),
+		server.WithWriteTimeout(0),
+	), nil
+}
+
+// NewHandler creates the router of the admin endpoints. Every endpoint
+// requires authentication, so either config.Admin.Token or opts.Auth must
+// be set.
+func NewHandler(cfg *config.Config, log logger.Logger, opts Options) (http.Handler, error) {
+	auth := opts.Auth
+	if auth == nil {
+		if cfg.Admin.Token == "" {
+			return nil, fmt.Errorf("admin server requires admin.token or an auth middleware")
+		}
+		auth = bearerToken(cfg.Admin.Token)
+	}
+
+	h := &handler{
+		config: cfg,
+		log:    log.WithComponent("admin"),
+		opts:   opts,
+		binder: server.NewBinder(cfg),
+	}
+
+	router := server.NewRouter()
+	router.Use(
+		server.RequestID(),
+		server.Problems(server.NewProblemWriter(server.DefaultStatuses, cfg.HTTP.Debug)),
+		server.AccessLog(h.log),
+		server.Recover(h.log),
+		auth,
+	)
+
+	// Profiling
+	router.HandleFunc(http.MethodGet, "/debug/pprof", pprofIndex)
+	router.HandleFunc(http.MethodGet, "/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc(http.MethodGet, "/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc(http.MethodGet, "/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc(http.MethodPost, "/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc(http.MethodGet, "/debug/pprof/trace", pprof.Trace)
+	router.HandleFunc(http.MethodGet, "/debug/pprof/{profile}", pprof.Index)
+	router.Get("/debug/goroutines", h.goroutines)
+
+	// Diagnostics
+	router.Get("/admin/config", h.effectiveConfig)
+	if opts.Database != nil {
+		router.Get("/admin/database", h.databaseStats)
+	}
+	if len(opts.Pools) > 0 {
+		router.Get("/admin/pools", h.pools)
+	}
+	server.AttachMetrics(router, cfg, opts.Metrics)
+
+	// Runtime controls
+	if levels, ok := log.(logger.LevelController); ok {
+		h.levels = levels
+		router.Get("/admin/loglevel", h.logLevel)
+		router.Put("/admin/loglevel", h.setLogLevel)
+	}
+	if opts.Features != nil {
+		router.Get("/admin/features", h.features)
+		router.Put("/admin/features/{name}", h.setFeature)
+	}
+
+	return router, nil
+}
+
+// bearerToken authenticates requests presenting token as a bearer token
+func bearerToken(token string) server.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, presented, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimSpace(presented)), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				server.WriteError(w, r, apperrors.New(server.CodeUnauthorized, "admin authentication required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// pprofIndex serves the profile index, redirecting /debug/pprof to
+// /debug/pprof/ as the index links to profiles by relative path
+func pprofIndex(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+	pprof.Index(w, r)
+}
+
+// goroutines writes the stacks of all goroutines
+func (h *handler) goroutines(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintf(w, "goroutines: %d\n\n", runtime.NumGoroutine())
+	runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// effectiveConfig writes the loaded configuration with secrets redacted
+func (h *handler) effectiveConfig(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(h.config)
+	if err != nil {
+		server.WriteError(w, r, apperrors.Wrap(err, server.CodeInternal, "failed to encode config"))
+		return
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		server.WriteError(w, r, apperrors.Wrap(err, server.CodeInternal, "failed to encode config"))
+		return
+	}
+	writeJSON(w, http.StatusOK, redact(doc, false))
+}
+
+// databaseStats writes the database connection pool statistics
+func (h *handler) databaseStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.opts.Database.Stats()
+	writeJSON(w, http.StatusOK, DatabaseStats{
+		OpenConnections: stats.OpenConnections,
+		InUse:           stats.InUse,
+		Idle:            stats.Idle,
+		WaitCount:       stats.WaitCount,
+		WaitDuration:    stats.WaitDuration.String(),
+		MaxIdleTime:     stats.MaxIdleTime.String(),
+	})
+}
+
+// pools writes the state of every worker pool
+func (h *handler) pools(w http.ResponseWriter, r *http.Request) {
+	result := make(map[string]PoolStatus, len(h.opts.Pools))
+	for name, pool := range h.opts.Pools {
+		result[name] = PoolStatus{
+			Size:   pool.Size(),
+			Active: pool.ActiveTasks(),
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// logLevel writes the current log level
+func (h *handler) logLevel(w http.ResponseWriter, r *http.Request) {
+	level := h.levels.Level()
+	writeJSON(w, http.StatusOK, LogLevel{Level: strings.ToLower(level.String())})
+}
+
+// setLogLevel changes the log level of every logger sharing it
+func (h *handler) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevel
+	if err := h.binder.Bind(w, r, &req); err != nil {
+		server.WriteError(w, r, err)
+		return
+	}
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		server.WriteError(w, r, apperrors.Wrap(err, server.CodeBadRequest, "invalid log level"))
+		return
+	}
+
+	previous := h.levels.Level()
+	h.levels.SetLevel(level)
+	h.log.Warn(r.Context(), "log level changed",
+		logger.Field{Key: "from", Value: previous.String()},
+		logger.Field{Key: "to", Value: level.String()},
+	)
+	writeJSON(w, http.StatusOK, LogLevel{Level: strings.ToLower(level.String())})
+}
+
+// features writes the state of every feature flag
+func (h *handler) features(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.opts.Features.All())
+}
+
+// setFeature enables or disables a feature flag
+func (h *handler) setFeature(w http.ResponseWriter, r *http.Request) {
+	var req FeatureFlag
+	if err := h.binder.Bind(w, r, &req); err != nil {
+		server.WriteError(w, r, err)
+		return
+	}
+
+	name := server.Param(r, "name")
+	if err := h.opts.Features.Set(name, *req.Enabled); err != nil {
+		server.WriteError(w, r, apperrors.Wrap(err, server.CodeNotFound, err.Error()))
+		return
+	}
+	h.log.Warn(r.Context(), "feature flag changed",
+		logger.Field{Key: "flag", Value: name},
+		logger.Field{Key: "enabled", Value: *req.Enabled},
+	)
+	writeJSON(w, http.StatusOK, FeatureFlag{Name: name, Enabled: req.Enabled})
+}
+
+// redact replaces string values under sensitive keys and passwords in URLs
+func redact(v interface{}, sensitive bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = redact(item, sensitive || isSensitive(k))
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redact(item, sensitive)
+		}
+		return value
+	case string:
+		if value == "" {
+			return value
+		}
+		if sensitive {
+			return redacted
+		}
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// isSensitive reports whether a configuration key holds a secret
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON writes v as an uncached JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
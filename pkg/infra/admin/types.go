@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"order-system/pkg/infra/concurrent"
+	"order-system/pkg/infra/database"
+	"order-system/pkg/infra/server"
+	"order-system/pkg/platform/features"
+	"order-system/pkg/platform/metrics"
+)
+
+// Options represents the components inspected and controlled through the
+// admin server. Endpoints of absent components are not registered.
+type Options struct {
+	Database database.Database
+	Pools    map[string]*concurrent.Pool
+	Features *features.Flags
+	// Metrics is served at config.Metrics.Endpoint when metrics are enabled
+	Metrics metrics.Collector
+
+	// Auth authenticates admin requests instead of the bearer token of
+	// config.Admin.Token, e.g. a JWT verifier followed by RequireRoles
+	Auth server.Middleware
+}
+
+// DatabaseStats represents the connection pool statistics of the database
+type DatabaseStats struct {
+	OpenConnections int    `json:"openConnections"`
+	InUse           int    `json:"inUse"`
+	Idle            int    `json:"idle"`
+	WaitCount       int64  `json:"waitCount"`
+	WaitDuration    string `json:"waitDuration"`
+	MaxIdleTime     string `json:"maxIdleTime"`
+}
+
+// PoolStatus represents the state of a worker pool
+type PoolStatus struct {
+	Size   int `json:"size"`
+	Active int `json:"active"`
+}
+
+// LogLevel represents the runtime log level
+type LogLevel struct {
+	Level string `json:"level" validate:"required,enum=debug|info|warn|error"`
+}
+
+// FeatureFlag represents the state of a feature flag
+type FeatureFlag struct {
+	Name    string `json:"name"`
+	Enabled *bool  `json:"enabled" validate:"required"`
+}
@@ -56,3 +56,8 @@ func (p *Pool) Close() {
 func (p *Pool) ActiveTasks() int {
 	return len(p.workers)
 }
+
+// Size returns the maximum number of concurrently running tasks
+func (p *Pool) Size() int {
+	return cap(p.workers)
+}
@@ -3,7 +3,10 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -78,6 +81,75 @@ func (p *Provider) validate(config *Config) error {
 		return fmt.Errorf("http.writeTimeout must be positive")
 	}
 
+	// Validate HTTP client settings
+	if err := validateTransport("httpClient.transport", config.HTTPClient.Transport); err != nil {
+		return err
+	}
+	for name, transport := range config.HTTPClient.Clients {
+		if err := validateTransport("httpClient.clients."+name, transport); err != nil {
+			return err
+		}
+	}
+
+	for i, rule := range config.HTTPClient.Faults.Rules {
+		if err := validateFaultRule(fmt.Sprintf("httpClient.faults.rules[%d]", i), rule); err != nil {
+			return err
+		}
+	}
+
+	// Validate rate limiting settings
+	if err := validateQuota("rateLimit.default", config.RateLimit.Default); err != nil {
+		return err
+	}
+	for _, proxy := range config.RateLimit.TrustedProxies {
+		if !validIPOrCIDR(proxy) {
+			return fmt.Errorf("invalid rateLimit.trustedProxies entry: %s", proxy)
+		}
+	}
+	for i, route := range config.RateLimit.Routes {
+		prefix := fmt.Sprintf("rateLimit.routes[%d]", i)
+		if route.Route == "" {
+			return fmt.Errorf("%s.route is required", prefix)
+		}
+		if err := validateQuota(prefix+".quota", route.Quota); err != nil {
+			return err
+		}
+	}
+
+	// Validate CORS settings
+	for _, origin := range config.CORS.AllowedOrigins {
+		if origin == "*" {
+			if config.CORS.AllowCredentials {
+				return fmt.Errorf("cors.allowCredentials cannot be used with the * origin")
+			}
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "*.", "", 1))
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("invalid cors.allowedOrigins entry: %s", origin)
+		}
+	}
+	if config.CORS.MaxAge < 0 {
+		return fmt.Errorf("cors.maxAge must not be negative")
+	}
+
+	// Validate security headers
+	switch strings.ToUpper(config.SecurityHeaders.FrameOptions) {
+	case "", "-", "DENY", "SAMEORIGIN":
+	default:
+		return fmt.Errorf("invalid securityHeaders.frameOptions: %s", config.SecurityHeaders.FrameOptions)
+	}
+
+	// Validate admin settings
+	if config.Admin.Enabled {
+		if config.Admin.Port <= 0 || config.Admin.Port > 65535 {
+			return fmt.Errorf("admin.port must be between 1 and 65535")
+		}
+		if config.Admin.Port == config.HTTP.Port {
+			return fmt.Errorf("admin.port must differ from http.port")
+		}
+	}
+
 	// Validate Logger settings
 	level := strings.ToLower(config.Logger.Level)
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
@@ -98,6 +170,69 @@ func (p *Provider) validate(config *Config) error {
 	return nil
 }
 
+// validateTransport validates HTTP client transport settings
+func validateTransport(prefix string, t TransportConfig) error {
+	if t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 || t.MaxConnsPerHost < 0 {
+		return fmt.Errorf("%s connection limits must not be negative", prefix)
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("%s.certFile and %s.keyFile must be set together", prefix, prefix)
+	}
+	switch t.MinTLSVersion {
+	case "", "1.0", "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("invalid %s.minTLSVersion: %s", prefix, t.MinTLSVersion)
+	}
+	if t.ProxyURL != "" {
+		if _, err := url.Parse(t.ProxyURL); err != nil {
+			return fmt.Errorf("invalid %s.proxyURL: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+// validateFaultRule validates a fault injection rule
+func validateFaultRule(prefix string, r FaultRule) error {
+	probabilities := map[string]float64{
+		"latencyProbability":  r.LatencyProbability,
+		"statusProbability":   r.StatusProbability,
+		"resetProbability":    r.ResetProbability,
+		"truncateProbability": r.TruncateProbability,
+	}
+	for name, p := range probabilities {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s.%s must be between 0 and 1", prefix, name)
+		}
+	}
+	if r.StatusProbability > 0 && (r.StatusCode < 100 || r.StatusCode > 599) {
+		return fmt.Errorf("%s.statusCode must be a valid HTTP status", prefix)
+	}
+	if _, err := path.Match(r.Route, "/"); err != nil {
+		return fmt.Errorf("invalid %s.route: %w", prefix, err)
+	}
+	return nil
+}
+
+// validateQuota validates a rate limit quota
+func validateQuota(prefix string, q RateLimitQuota) error {
+	if q.Requests < 0 || q.Burst < 0 {
+		return fmt.Errorf("%s.requests and %s.burst must not be negative", prefix, prefix)
+	}
+	if q.Requests > 0 && q.Period <= 0 {
+		return fmt.Errorf("%s.period must be positive", prefix)
+	}
+	return nil
+}
+
+// validIPOrCIDR reports whether s is an IP address or a CIDR block
+func validIPOrCIDR(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
 // GetConfigPath returns the absolute path for a config file
 func (p *Provider) GetConfigPath(env string) string {
 	if env == "" {
@@ -27,8 +27,31 @@ type Config struct {
 		MaxRequestSize  int64         `json:"maxRequestSize"`
 		RequestTimeout  time.Duration `json:"requestTimeout"`
 		ShutdownTimeout time.Duration `json:"shutdownTimeout"`
+		Debug           bool          `json:"debug"`
 	} `json:"http"`
 
+	// HTTP client settings
+	HTTPClient struct {
+		Transport TransportConfig            `json:"transport"`
+		Clients   map[string]TransportConfig `json:"clients"`
+		Faults    FaultConfig                `json:"faults"`
+	} `json:"httpClient"`
+
+	// Inbound rate limiting settings
+	RateLimit RateLimitConfig `json:"rateLimit"`
+
+	// Cross-origin request settings
+	CORS CORSConfig `json:"cors"`
+
+	// Security response header settings
+	SecurityHeaders SecurityHeadersConfig `json:"securityHeaders"`
+
+	// Admin server settings
+	Admin AdminConfig `json:"admin"`
+
+	// Feature flags and their initial state
+	Features map[string]bool `json:"features"`
+
 	// Logger settings
 	Logger struct {
 		Level      string `json:"level"`
@@ -45,3 +68,162 @@ type Config struct {
 		Interval    time.Duration `json:"interval"`
 	} `json:"metrics"`
 }
+
+// TransportConfig represents connection, TLS and proxy settings of an HTTP client.
+// Zero values fall back to the defaults, or to the shared settings for a named client.
+type TransportConfig struct {
+	MaxIdleConns        int           `json:"maxIdleConns"`
+	MaxIdleConnsPerHost int           `json:"maxIdleConnsPerHost"`
+	MaxConnsPerHost     int           `json:"maxConnsPerHost"`
+	IdleConnTimeout     time.Duration `json:"idleConnTimeout"`
+	DialTimeout         time.Duration `json:"dialTimeout"`
+	KeepAlive           time.Duration `json:"keepAlive"`
+	TLSHandshakeTimeout time.Duration `json:"tlsHandshakeTimeout"`
+	EnableHTTP2         *bool         `json:"enableHTTP2"`
+	CAFile              string        `json:"caFile"`
+	CertFile            string        `json:"certFile"`
+	KeyFile             string        `json:"keyFile"`
+	MinTLSVersion       string        `json:"minTLSVersion"`
+	ProxyURL            string        `json:"proxyURL"`
+}
+
+// FaultConfig represents fault injection settings for HTTP clients
+type FaultConfig struct {
+	Enabled bool        `json:"enabled"`
+	Rules   []FaultRule `json:"rules"`
+}
+
+// FaultRule represents the faults injected into matching requests. Route is a
+// path pattern as understood by path.Match; empty Client, Method and Route
+// match every request. Probabilities range from 0 to 1.
+type FaultRule struct {
+	Client string `json:"client"`
+	Method string `json:"method"`
+	Route  string `json:"route"`
+
+	LatencyProbability  float64       `json:"latencyProbability"`
+	Latency             time.Duration `json:"latency"`
+	StatusProbability   float64       `json:"statusProbability"`
+	StatusCode          int           `json:"statusCode"`
+	ResetProbability    float64       `json:"resetProbability"`
+	TruncateProbability float64       `json:"truncateProbability"`
+	TruncateAfter       int64         `json:"truncateAfter"`
+}
+
+// RateLimitConfig represents inbound rate limiting settings. Clients are
+// identified by authenticated API key, then by authenticated user, then by
+// IP address.
+type RateLimitConfig struct {
+	Enabled bool `json:"enabled"`
+	// TrustedProxies lists the IPs or CIDRs of proxies whose X-Forwarded-For
+	// entries are trusted; the client IP is the rightmost untrusted hop
+	TrustedProxies []string `json:"trustedProxies"`
+
+	// Default applies to routes without a specific quota
+	Default RateLimitQuota   `json:"default"`
+	Routes  []RateLimitRoute `json:"routes"`
+}
+
+// RateLimitQuota represents a token bucket refilled with Requests tokens per
+// Period and holding at most Burst tokens, which defaults to Requests. A zero
+// quota disables limiting.
+type RateLimitQuota struct {
+	Requests int           `json:"requests"`
+	Period   time.Duration `json:"period"`
+	Burst    int           `json:"burst"`
+}
+
+// RateLimitRoute represents the quota of a route template, e.g.
+// "/orders/{id}"; an empty Method matches every method
+type RateLimitRoute struct {
+	Method string         `json:"method"`
+	Route  string         `json:"route"`
+	Quota  RateLimitQuota `json:"quota"`
+}
+
+// CORSConfig represents cross-origin request settings. Origins are exact
+// ("https://shop.example.com"), wildcard subdomains ("https://*.example.com")
+// or "*" for any origin. CORS is disabled when no origin is allowed.
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+	// AllowedMethods defaults to GET, HEAD and POST
+	AllowedMethods []string `json:"allowedMethods"`
+	// AllowedHeaders lists request headers clients may send; "*" allows any
+	AllowedHeaders   []string      `json:"allowedHeaders"`
+	ExposedHeaders   []string      `json:"exposedHeaders"`
+	AllowCredentials bool          `json:"allowCredentials"`
+	MaxAge           time.Duration `json:"maxAge"`
+}
+
+// SecurityHeadersConfig represents security response headers. Empty values
+// fall back to defaults suited to a JSON API; "-" omits a header.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge enables Strict-Transport-Security when positive
+	HSTSMaxAge            time.Duration `json:"hstsMaxAge"`
+	HSTSIncludeSubdomains bool          `json:"hstsIncludeSubdomains"`
+	HSTSPreload           bool          `json:"hstsPreload"`
+	// FrameOptions defaults to DENY
+	FrameOptions string `json:"frameOptions"`
+	// ContentSecurityPolicy defaults to "default-src 'none'; frame-ancestors 'none'"
+	ContentSecurityPolicy string `json:"contentSecurityPolicy"`
+	// ReferrerPolicy defaults to no-referrer
+	ReferrerPolicy string `json:"referrerPolicy"`
+}
+
+// AdminConfig represents the settings of the admin server, which exposes
+// profiling, diagnostics and runtime controls on a separate port
+type AdminConfig struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+	// Token is the bearer token admin requests must present
+	Token string `json:"token"`
+}
+
+// ClientTransport returns the transport settings for the named client,
+// applying its overrides on top of the shared settings
+func (c *Config) ClientTransport(name string) TransportConfig {
+	result := c.HTTPClient.Transport
+	override, ok := c.HTTPClient.Clients[name]
+	if !ok {
+		return result
+	}
+
+	if override.MaxIdleConns != 0 {
+		result.MaxIdleConns = override.MaxIdleConns
+	}
+	if override.MaxIdleConnsPerHost != 0 {
+		result.MaxIdleConnsPerHost = override.MaxIdleConnsPerHost
+	}
+	if override.MaxConnsPerHost != 0 {
+		result.MaxConnsPerHost = override.MaxConnsPerHost
+	}
+	if override.IdleConnTimeout != 0 {
+		result.IdleConnTimeout = override.IdleConnTimeout
+	}
+	if override.DialTimeout != 0 {
+		result.DialTimeout = override.DialTimeout
+	}
+	if override.KeepAlive != 0 {
+		result.KeepAlive = override.KeepAlive
+	}
+	if override.TLSHandshakeTimeout != 0 {
+		result.TLSHandshakeTimeout = override.TLSHandshakeTimeout
+	}
+	if override.EnableHTTP2 != nil {
+		result.EnableHTTP2 = override.EnableHTTP2
+	}
+	if override.CAFile != "" {
+		result.CAFile = override.CAFile
+	}
+	if override.CertFile != "" {
+		result.CertFile = override.CertFile
+		result.KeyFile = override.KeyFile
+	}
+	if override.MinTLSVersion != "" {
+		result.MinTLSVersion = override.MinTLSVersion
+	}
+	if override.ProxyURL != "" {
+		result.ProxyURL = override.ProxyURL
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"order-system/pkg/infra/config"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -403,3 +405,38 @@ func TestDB_Stats(t *testing.T) {
 	assert.IsType(t, time.Duration(0), stats.WaitDuration)
 	assert.IsType(t, time.Duration(0), stats.MaxIdleTime)
 }
+
+func TestIsDuplicate(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "mysql duplicate entry",
+			err:      &Error{Operation: "exec", Err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'PRIMARY'"}},
+			expected: true,
+		},
+		{
+			name:     "other mysql error",
+			err:      &Error{Operation: "exec", Err: &mysql.MySQLError{Number: 1146, Message: "Table 'orders' doesn't exist"}},
+			expected: false,
+		},
+		{
+			name:     "short message",
+			err:      &Error{Operation: "exec", Err: errors.New("eof")},
+			expected: false,
+		},
+		{
+			name:     "not a database error",
+			err:      sql.ErrNoRows,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsDuplicate(tt.err))
+		})
+	}
+}
@@ -3,8 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 // Row represents a database row
@@ -66,7 +70,11 @@ func IsNoRows(err error) bool {
 func IsDuplicate(err error) bool {
 	if dbErr, ok := err.(*Error); ok {
 		// Check MySQL error code 1062 (duplicate entry)
-		return dbErr.Err.Error()[0:4] == "1062"
+		var mysqlErr *mysql.MySQLError
+		if errors.As(dbErr.Err, &mysqlErr) {
+			return mysqlErr.Number == 1062
+		}
+		return dbErr.Err != nil && strings.HasPrefix(dbErr.Err.Error(), "1062")
 	}
 	return false
 }
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"order-system/pkg/infra/database"
+	httpclient "order-system/pkg/infra/http"
+)
+
+// maxDependencyBody bounds the response body read by HTTPDependency
+const maxDependencyBody = 64 * 1024
+
+// DatabasePing checks that the database answers a trivial query
+func DatabasePing(db database.Database) CheckFunc {
+	return func(ctx context.Context) error {
+		var one int
+		if err := db.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+			return fmt.Errorf("database ping failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// StatsThresholds represents connection pool limits checked by DatabaseStats.
+// Zero values disable the corresponding limit.
+type StatsThresholds struct {
+	// MaxInUse is the maximum number of connections in use
+	MaxInUse int
+	// MaxWaitCountGrowth is the maximum number of new waits for a connection
+	// between two runs of the check
+	MaxWaitCountGrowth int64
+	// MaxWaitDurationGrowth is the maximum time spent waiting for connections
+	// between two runs of the check
+	MaxWaitDurationGrowth time.Duration
+}
+
+// DatabaseStats checks connection pool statistics against thresholds. Wait
+// growth is measured since the previous run, so the first run only records
+// a baseline.
+func DatabaseStats(db database.Database, t StatsThresholds) CheckFunc {
+	var (
+		mu   sync.Mutex
+		last *database.Stats
+	)
+	return func(ctx context.Context) error {
+		stats := db.Stats()
+
+		mu.Lock()
+		prev := last
+		last = &stats
+		mu.Unlock()
+
+		if t.MaxInUse > 0 && stats.InUse > t.MaxInUse {
+			return fmt.Errorf("%d connections in use, limit is %d", stats.InUse, t.MaxInUse)
+		}
+		if prev == nil {
+			return nil
+		}
+		if growth := stats.WaitCount - prev.WaitCount; t.MaxWaitCountGrowth > 0 && growth > t.MaxWaitCountGrowth {
+			return fmt.Errorf("wait count grew by %d, limit is %d", growth, t.MaxWaitCountGrowth)
+		}
+		if growth := stats.WaitDuration - prev.WaitDuration; t.MaxWaitDurationGrowth > 0 && growth > t.MaxWaitDurationGrowth {
+			return fmt.Errorf("wait duration grew by %s, limit is %s", growth, t.MaxWaitDurationGrowth)
+		}
+		return nil
+	}
+}
+
+// HTTPDependency checks that a downstream service answers path with a
+// non-error status. The request is not retried; the check timeout applies.
+func HTTPDependency(client httpclient.Client, path string) CheckFunc {
+	return func(ctx context.Context) error {
+		resp, err := client.Get(ctx, path, &httpclient.RequestOption{
+			MaxBodySize: maxDependencyBody,
+			Route:       path,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("%s returned status %d", path, resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/database"
+	"order-system/pkg/infra/health"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDB implements database.Database for checks
+type fakeDB struct {
+	database.Database
+	pingErr error
+	stats   database.Stats
+}
+
+type fakeRow struct {
+	err error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int) = 1
+	return nil
+}
+
+func (d *fakeDB) QueryRow(ctx context.Context, query string, args ...interface{}) database.Row {
+	return fakeRow{err: d.pingErr}
+}
+
+func (d *fakeDB) Stats() database.Stats {
+	return d.stats
+}
+
+func TestDatabasePing(t *testing.T) {
+	db := &fakeDB{}
+	assert.NoError(t, health.DatabasePing(db)(context.Background()))
+
+	db.pingErr = errors.New("bad connection")
+	err := health.DatabasePing(db)(context.Background())
+	assert.ErrorContains(t, err, "bad connection")
+}
+
+func TestDatabaseStats(t *testing.T) {
+	db := &fakeDB{stats: database.Stats{InUse: 2, WaitCount: 10, WaitDuration: time.Second}}
+	check := health.DatabaseStats(db, health.StatsThresholds{
+		MaxInUse:              5,
+		MaxWaitCountGrowth:    3,
+		MaxWaitDurationGrowth: time.Second,
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, check(ctx))
+
+	db.stats.WaitCount = 12
+	assert.NoError(t, check(ctx))
+
+	db.stats.WaitCount = 20
+	assert.ErrorContains(t, check(ctx), "wait count grew by 8")
+
+	db.stats.WaitDuration = 3 * time.Second
+	assert.ErrorContains(t, check(ctx), "wait duration grew")
+
+	db.stats.InUse = 6
+	assert.ErrorContains(t, check(ctx), "6 connections in use")
+}
+
+func TestHTTPDependency(t *testing.T) {
+	status := http.StatusOK
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer downstream.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 5 * time.Second
+	check := health.HTTPDependency(httpclient.NewClient(cfg, downstream.URL), "/health")
+
+	assert.NoError(t, check(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, check(context.Background()), "returned status 503")
+}
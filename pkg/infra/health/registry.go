@@ -0,0 +1,202 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"order-system/pkg/infra/server"
+)
+
+// check represents a registered check and its cached result
+type check struct {
+	name string
+	fn   CheckFunc
+	opts CheckOptions
+
+	mu     sync.Mutex
+	result Result
+	valid  bool
+}
+
+// Registry holds named health checks and serves liveness and readiness
+type Registry struct {
+	mu       sync.RWMutex
+	checks   map[string]*check
+	draining bool
+}
+
+// NewRegistry creates a new Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]*check),
+	}
+}
+
+// Register adds a named check, replacing any check with the same name
+func (r *Registry) Register(name string, fn CheckFunc, opts CheckOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = defaultCacheTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = &check{name: name, fn: fn, opts: opts}
+}
+
+// SetDraining marks the service as shutting down, making readiness fail
+func (r *Registry) SetDraining(draining bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draining = draining
+}
+
+// Attach registers /healthz and /readyz on router and flips readiness to
+// false when srv begins graceful shutdown
+func (r *Registry) Attach(router *server.Router, srv *server.Server) {
+	router.Get("/healthz", r.LivenessHandler())
+	router.Get("/readyz", r.ReadinessHandler())
+	if srv != nil {
+		srv.OnShutdown(func() { r.SetDraining(true) })
+	}
+}
+
+// Liveness runs the liveness checks
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+// Readiness runs all checks. It reports down while the service is draining.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	report := r.run(ctx, false)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.draining {
+		report.Status = StatusDown
+		report.ShuttingDown = true
+	}
+	return report
+}
+
+// LivenessHandler serves the liveness report
+func (r *Registry) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, r.Liveness(req.Context()))
+	}
+}
+
+// ReadinessHandler serves the readiness report
+func (r *Registry) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, r.Readiness(req.Context()))
+	}
+}
+
+// run executes the selected checks concurrently
+func (r *Registry) run(ctx context.Context, liveness bool) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if !liveness || c.opts.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Result, len(checks)),
+	}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run executes the check, or returns its cached result if still fresh.
+// Concurrent callers wait for a single run.
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.valid && c.opts.CacheTTL > 0 && time.Since(c.result.CheckedAt) < c.opts.CacheTTL {
+		return c.result
+	}
+
+	// The result is shared and cached, so it must not depend on whether the
+	// probe that triggered the run went away
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.call(ctx)
+	result := Result{
+		Status:     StatusUp,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	c.result = result
+	c.valid = true
+	return result
+}
+
+// call runs the check function, failing when it exceeds the timeout even if
+// it ignores ctx and converting panics into errors
+func (c *check) call(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("check panicked: %v", v)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out after %s", c.opts.Timeout)
+	}
+}
+
+// writeReport writes a report as JSON, with 503 if it is down
+func writeReport(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if report.Status != StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
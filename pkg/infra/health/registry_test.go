@@ -0,0 +1,136 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/health"
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getReport(t *testing.T, h http.Handler, path string) (int, health.Report) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestRegistryEndpoints(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("goroutines", func(ctx context.Context) error { return nil }, health.CheckOptions{Liveness: true})
+	registry.Register("database", func(ctx context.Context) error { return errors.New("connection refused") }, health.CheckOptions{})
+
+	router := server.NewRouter()
+	registry.Attach(router, nil)
+
+	code, report := getReport(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Equal(t, health.StatusUp, report.Checks["goroutines"].Status)
+
+	code, report = getReport(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, health.StatusDown, report.Checks["database"].Status)
+	assert.Equal(t, "connection refused", report.Checks["database"].Error)
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, health.CheckOptions{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	report := registry.Readiness(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Contains(t, report.Checks["stuck"].Error, "timed out")
+}
+
+func TestRegistryCaching(t *testing.T) {
+	var calls int32
+	registry := health.NewRegistry()
+	registry.Register("cached", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, health.CheckOptions{CacheTTL: time.Hour})
+	registry.Register("uncached", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 100)
+		return nil
+	}, health.CheckOptions{CacheTTL: -1})
+
+	for i := 0; i < 3; i++ {
+		registry.Readiness(context.Background())
+	}
+	assert.Equal(t, int32(301), atomic.LoadInt32(&calls))
+}
+
+func TestRegistryCancelledProbe(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("database", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		}
+	}, health.CheckOptions{CacheTTL: time.Hour})
+
+	// A probe giving up does not fail the check cached for later probes
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	registry.Readiness(ctx)
+
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, health.StatusUp, report.Status)
+}
+
+func TestRegistryPanickingCheck(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("broken", func(ctx context.Context) error { panic("boom") }, health.CheckOptions{})
+
+	report := registry.Readiness(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Contains(t, report.Checks["broken"].Error, "boom")
+}
+
+func TestReadinessDuringShutdown(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.HTTP.ShutdownTimeout = time.Second
+
+	registry := health.NewRegistry()
+	router := server.NewRouter()
+	srv := server.New(cfg, router, server.WithListener(server.NewRandomListener()))
+	registry.Attach(router, srv)
+
+	code, report := getReport(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, report.ShuttingDown)
+
+	require.NoError(t, srv.Start())
+	require.NoError(t, srv.Shutdown(context.Background()))
+
+	code, report = getReport(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, report.ShuttingDown)
+
+	code, _ = getReport(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Status represents the state of a check or of the service
+type Status string
+
+const (
+	// StatusUp means the check passed
+	StatusUp Status = "up"
+	// StatusDown means the check failed
+	StatusDown Status = "down"
+)
+
+// Default check settings
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// CheckFunc reports whether a component is healthy. It must honor ctx.
+type CheckFunc func(ctx context.Context) error
+
+// CheckOptions represents the settings of a registered check
+type CheckOptions struct {
+	// Timeout bounds a single run of the check; defaults to 2s
+	Timeout time.Duration
+	// CacheTTL is how long a result is reused; defaults to 5s, negative disables caching
+	CacheTTL time.Duration
+	// Liveness includes the check in /healthz. Checks of external
+	// dependencies should only affect readiness.
+	Liveness bool
+}
+
+// Result represents the outcome of a check
+type Result struct {
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// Report represents the outcome of a set of checks
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+	// ShuttingDown is set by readiness reports during graceful shutdown
+	ShuttingDown bool `json:"shuttingDown,omitempty"`
+}
@@ -0,0 +1,267 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bearerAuth implements AuthProvider with a static bearer token
+type bearerAuth struct {
+	token string
+}
+
+// NewBearerAuth creates an auth provider that sends a static bearer token
+func NewBearerAuth(token string) AuthProvider {
+	return &bearerAuth{token: token}
+}
+
+// Authenticate implements AuthProvider.Authenticate
+func (a *bearerAuth) Authenticate(ctx context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// basicAuth implements AuthProvider with HTTP basic authentication
+type basicAuth struct {
+	username string
+	password string
+}
+
+// NewBasicAuth creates an auth provider that uses HTTP basic authentication
+func NewBasicAuth(username, password string) AuthProvider {
+	return &basicAuth{
+		username: username,
+		password: password,
+	}
+}
+
+// Authenticate implements AuthProvider.Authenticate
+func (a *basicAuth) Authenticate(ctx context.Context, req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+// OAuth2Config represents the settings of an OAuth2 client-credentials provider
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// RefreshBefore renews the token in the background this long before it expires
+	RefreshBefore time.Duration
+	// HTTPClient is used to call the token endpoint; http.DefaultClient if nil
+	HTTPClient *http.Client
+}
+
+// oauth2Auth implements AuthProvider using the OAuth2 client-credentials grant
+type oauth2Auth struct {
+	cfg OAuth2Config
+
+	mu         sync.Mutex
+	token      string
+	expiry     time.Time
+	refreshing bool
+}
+
+// NewOAuth2ClientCredentials creates an auth provider that obtains and caches
+// access tokens using the OAuth2 client-credentials grant
+func NewOAuth2ClientCredentials(cfg OAuth2Config) AuthProvider {
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = 30 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &oauth2Auth{cfg: cfg}
+}
+
+// Authenticate implements AuthProvider.Authenticate
+func (a *oauth2Auth) Authenticate(ctx context.Context, req *http.Request) error {
+	token, err := a.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Refresh implements Refresher.Refresh
+func (a *oauth2Auth) Refresh(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.token = ""
+	return a.fetchLocked(ctx)
+}
+
+// currentToken returns a valid token, fetching a new one if the cached token
+// has expired and refreshing it in the background when it is about to expire
+func (a *oauth2Auth) currentToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if a.token == "" || !now.Before(a.expiry) {
+		if err := a.fetchLocked(ctx); err != nil {
+			return "", err
+		}
+		return a.token, nil
+	}
+
+	if !now.Before(a.expiry.Add(-a.cfg.RefreshBefore)) && !a.refreshing {
+		a.refreshing = true
+		go a.backgroundRefresh()
+	}
+	return a.token, nil
+}
+
+// backgroundRefresh renews the token while the current one is still in use
+func (a *oauth2Auth) backgroundRefresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.RefreshBefore)
+	defer cancel()
+
+	token, expiry, err := a.requestToken(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refreshing = false
+	if err == nil {
+		a.token = token
+		a.expiry = expiry
+	}
+}
+
+// fetchLocked requests a new token; a.mu must be held
+func (a *oauth2Auth) fetchLocked(ctx context.Context) error {
+	token, expiry, err := a.requestToken(ctx)
+	if err != nil {
+		return err
+	}
+	a.token = token
+	a.expiry = expiry
+	return nil
+}
+
+// requestToken calls the token endpoint
+func (a *oauth2Auth) requestToken(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.cfg.ClientID), url.QueryEscape(a.cfg.ClientSecret))
+
+	start := time.Now()
+	resp, err := a.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, data)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token response has no access_token")
+	}
+
+	// Tokens without an expiry are treated as long-lived
+	expiry := start.Add(24 * time.Hour)
+	if token.ExpiresIn > 0 {
+		expiry = start.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token.AccessToken, expiry, nil
+}
+
+// HMACConfig represents the settings of an HMAC request signer
+type HMACConfig struct {
+	KeyID  string
+	Secret []byte
+
+	// SignatureHeader carries the signature; "Authorization" if empty
+	SignatureHeader string
+	// TimestampHeader carries the signing time; "X-Timestamp" if empty
+	TimestampHeader string
+}
+
+// hmacAuth implements AuthProvider by signing requests with HMAC-SHA256
+type hmacAuth struct {
+	cfg HMACConfig
+}
+
+// NewHMACAuth creates an auth provider that signs the method, path, body and
+// timestamp of every request with HMAC-SHA256
+func NewHMACAuth(cfg HMACConfig) AuthProvider {
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = "Authorization"
+	}
+	if cfg.TimestampHeader == "" {
+		cfg.TimestampHeader = "X-Timestamp"
+	}
+	return &hmacAuth{cfg: cfg}
+}
+
+// Authenticate implements AuthProvider.Authenticate
+func (a *hmacAuth) Authenticate(ctx context.Context, req *http.Request) error {
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read body for signing: %w", err)
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read body for signing: %w", err)
+		}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := HMACSignature(a.cfg.Secret, req.Method, req.URL.RequestURI(), timestamp, body)
+
+	req.Header.Set(a.cfg.TimestampHeader, timestamp)
+	req.Header.Set(a.cfg.SignatureHeader, fmt.Sprintf("HMAC-SHA256 keyId=%s, signature=%s", a.cfg.KeyID, signature))
+	return nil
+}
+
+// HMACSignature computes the hex-encoded HMAC-SHA256 signature of a request.
+// The signed string is the method, path, timestamp and hex SHA-256 of the
+// body, separated by newlines.
+func HMACSignature(secret []byte, method, path, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,202 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestBearerAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret-token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newAuthTestConfig(), server.URL,
+		httpclient.WithAuth(httpclient.NewBearerAuth("secret-token")))
+
+	resp, err := client.Get(context.Background(), "/orders", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newAuthTestConfig(), server.URL,
+		httpclient.WithAuth(httpclient.NewBasicAuth("user", "pass")))
+
+	_, err := client.Get(context.Background(), "/orders", nil)
+	require.NoError(t, err)
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "orders:read", r.PostForm.Get("scope"))
+		id, secret, _ := r.BasicAuth()
+		assert.Equal(t, "client", id)
+		assert.Equal(t, "secret", secret)
+
+		n := atomic.AddInt32(&tokenRequests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	auth := httpclient.NewOAuth2ClientCredentials(httpclient.OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"orders:read"},
+	})
+	client := httpclient.NewClient(newAuthTestConfig(), server.URL, httpclient.WithAuth(auth))
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(context.Background(), "/orders", nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+}
+
+func TestOAuth2ProactiveRefresh(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":60}`, n)
+	}))
+	defer tokenServer.Close()
+
+	auth := httpclient.NewOAuth2ClientCredentials(httpclient.OAuth2Config{
+		TokenURL:      tokenServer.URL,
+		RefreshBefore: 2 * time.Minute,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	require.NoError(t, auth.Authenticate(context.Background(), req))
+	assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+
+	// The token is within the refresh window, so it is still used while a
+	// new one is fetched in the background
+	require.NoError(t, auth.Authenticate(context.Background(), req))
+	assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+
+	assert.Eventually(t, func() bool {
+		require.NoError(t, auth.Authenticate(context.Background(), req))
+		return req.Header.Get("Authorization") != "Bearer token-1"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUnauthorizedRefreshesOnce(t *testing.T) {
+	var tokenRequests, apiRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiRequests, 1)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	auth := httpclient.NewOAuth2ClientCredentials(httpclient.OAuth2Config{TokenURL: tokenServer.URL})
+	client := httpclient.NewClient(newAuthTestConfig(), server.URL, httpclient.WithAuth(auth))
+
+	resp, err := client.Get(context.Background(), "/orders", &httpclient.RequestOption{MaxBodySize: 1024})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tokenRequests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&apiRequests))
+}
+
+func TestUnauthorizedWithoutRefresher(t *testing.T) {
+	var apiRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiRequests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newAuthTestConfig(), server.URL,
+		httpclient.WithAuth(httpclient.NewBearerAuth("expired")))
+
+	resp, err := client.Get(context.Background(), "/orders", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&apiRequests))
+}
+
+func TestHMACAuth(t *testing.T) {
+	secret := []byte("shared-secret")
+	body := `{"order_id":42}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		assert.Equal(t, body, string(data))
+
+		timestamp := r.Header.Get("X-Timestamp")
+		require.NotEmpty(t, timestamp)
+		expected := httpclient.HMACSignature(secret, r.Method, r.URL.RequestURI(), timestamp, data)
+
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "HMAC-SHA256 keyId=partner-1, "))
+		assert.True(t, strings.HasSuffix(auth, "signature="+expected))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newAuthTestConfig(), server.URL,
+		httpclient.WithAuth(httpclient.NewHMACAuth(httpclient.HMACConfig{
+			KeyID:  "partner-1",
+			Secret: secret,
+		})))
+
+	resp, err := client.Post(context.Background(), "/orders?source=web", []byte(body), nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,362 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"order-system/pkg/infra/config"
+)
+
+// BalancePolicy represents the strategy used to choose an endpoint
+type BalancePolicy int
+
+const (
+	// RoundRobin cycles through endpoints in order
+	RoundRobin BalancePolicy = iota
+	// LeastInFlight picks the endpoint with the fewest outstanding requests
+	LeastInFlight
+	// Weighted distributes requests in proportion to endpoint weights
+	Weighted
+)
+
+// Endpoint represents a backend the client can send requests to
+type Endpoint struct {
+	URL    string
+	Weight int
+}
+
+// EndpointStatus represents the current state of an endpoint
+type EndpointStatus struct {
+	URL                 string
+	Healthy             bool
+	InFlight            int
+	ConsecutiveFailures int
+	EjectedUntil        time.Time
+}
+
+// BalancerConfig represents load balancing and health checking settings
+type BalancerConfig struct {
+	Policy BalancePolicy
+
+	// MaxFailures is the number of consecutive failures that ejects an endpoint; defaults to 5
+	MaxFailures int
+	// EjectionTime is how long an ejected endpoint is skipped; defaults to 30s
+	EjectionTime time.Duration
+
+	// HealthPath enables active probes with a GET to this path on every endpoint
+	HealthPath     string
+	HealthInterval time.Duration
+	HealthTimeout  time.Duration
+}
+
+// endpoint tracks the state of a single backend
+type endpoint struct {
+	url           string
+	weight        int
+	currentWeight int
+	inFlight      int
+	failures      int
+	ejectedUntil  time.Time
+	probeHealthy  bool
+}
+
+// available reports whether the endpoint may receive traffic
+func (e *endpoint) available(now time.Time) bool {
+	return e.probeHealthy && !now.Before(e.ejectedUntil)
+}
+
+// endpointSet records the endpoints already tried by a request
+type endpointSet struct {
+	mu        sync.Mutex
+	endpoints map[*endpoint]bool
+}
+
+// newEndpointSet creates an empty endpoint set
+func newEndpointSet() *endpointSet {
+	return &endpointSet{endpoints: make(map[*endpoint]bool)}
+}
+
+// add records an endpoint
+func (s *endpointSet) add(ep *endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints[ep] = true
+}
+
+// contains reports whether an endpoint was recorded
+func (s *endpointSet) contains(ep *endpoint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endpoints[ep]
+}
+
+// Balancer distributes requests across several endpoints, ejecting failing
+// endpoints through passive outlier detection and optional active probes
+type Balancer struct {
+	mu        sync.Mutex
+	cfg       BalancerConfig
+	endpoints []*endpoint
+	next      int
+
+	probeClient *http.Client
+	stop        chan struct{}
+	wg          sync.WaitGroup
+	closeOnce   sync.Once
+}
+
+// NewBalancer creates a balancer over the given endpoints. Active health
+// probes start immediately when configured; call Close to stop them.
+func NewBalancer(endpoints []Endpoint, cfg BalancerConfig) (*Balancer, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("at least one endpoint is required")
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = 5
+	}
+	if cfg.EjectionTime <= 0 {
+		cfg.EjectionTime = 30 * time.Second
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 10 * time.Second
+	}
+	if cfg.HealthTimeout <= 0 {
+		cfg.HealthTimeout = 2 * time.Second
+	}
+
+	b := &Balancer{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+	}
+	for _, e := range endpoints {
+		if e.URL == "" {
+			return nil, fmt.Errorf("endpoint url is required")
+		}
+		weight := e.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		b.endpoints = append(b.endpoints, &endpoint{
+			url:          strings.TrimSuffix(e.URL, "/"),
+			weight:       weight,
+			probeHealthy: true,
+		})
+	}
+
+	if cfg.HealthPath != "" {
+		b.probeClient = &http.Client{Timeout: cfg.HealthTimeout}
+		b.wg.Add(1)
+		go b.probeLoop()
+	}
+
+	return b, nil
+}
+
+// WithBalancer makes the client send requests to the balancer's endpoints
+// instead of its base URL
+func WithBalancer(b *Balancer) ClientOption {
+	return func(c *defaultClient) {
+		c.balancer = b
+	}
+}
+
+// NewBalancedClient creates a client that load balances across endpoints
+func NewBalancedClient(cfg *config.Config, b *Balancer, opts ...ClientOption) Client {
+	return NewClient(cfg, "", append(opts, WithBalancer(b))...)
+}
+
+// Close stops active health probes
+func (b *Balancer) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.stop)
+	})
+	b.wg.Wait()
+	return nil
+}
+
+// Endpoints returns the current state of every endpoint
+func (b *Balancer) Endpoints() []EndpointStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]EndpointStatus, 0, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		statuses = append(statuses, EndpointStatus{
+			URL:                 ep.url,
+			Healthy:             ep.available(now),
+			InFlight:            ep.inFlight,
+			ConsecutiveFailures: ep.failures,
+			EjectedUntil:        ep.ejectedUntil,
+		})
+	}
+	return statuses
+}
+
+// acquire picks an endpoint, preferring available endpoints the request has
+// not tried yet. When every endpoint is unavailable all of them are
+// considered again so that an outage of the health signal does not stop all traffic.
+func (b *Balancer) acquire(tried *endpointSet) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	candidates := b.filter(func(ep *endpoint) bool {
+		return ep.available(now) && !tried.contains(ep)
+	})
+	if len(candidates) == 0 {
+		candidates = b.filter(func(ep *endpoint) bool { return ep.available(now) })
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+
+	ep := b.choose(candidates)
+	ep.inFlight++
+	tried.add(ep)
+	return ep
+}
+
+// release marks a request to the endpoint as finished
+func (b *Balancer) release(ep *endpoint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ep.inFlight--
+}
+
+// report records the outcome of a request for passive outlier detection
+func (b *Balancer) report(ep *endpoint, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		ep.failures = 0
+		return
+	}
+
+	ep.failures++
+	if ep.failures >= b.cfg.MaxFailures {
+		ep.ejectedUntil = time.Now().Add(b.cfg.EjectionTime)
+		ep.failures = 0
+	}
+}
+
+// isNetworkError reports whether err was caused by a failed connection
+func isNetworkError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isDialError reports whether err was caused by a connection that could not
+// be established, so the request never reached the endpoint and a retry
+// may fail over to another one
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// filter returns the endpoints matching fn; b.mu must be held
+func (b *Balancer) filter(fn func(*endpoint) bool) []*endpoint {
+	var result []*endpoint
+	for _, ep := range b.endpoints {
+		if fn(ep) {
+			result = append(result, ep)
+		}
+	}
+	return result
+}
+
+// choose applies the balancing policy to the candidates; b.mu must be held
+func (b *Balancer) choose(candidates []*endpoint) *endpoint {
+	switch b.cfg.Policy {
+	case LeastInFlight:
+		start := b.next % len(candidates)
+		b.next++
+		best := candidates[start]
+		for i := 1; i < len(candidates); i++ {
+			ep := candidates[(start+i)%len(candidates)]
+			if ep.inFlight < best.inFlight {
+				best = ep
+			}
+		}
+		return best
+	case Weighted:
+		// Smooth weighted round-robin
+		total := 0
+		var best *endpoint
+		for _, ep := range candidates {
+			ep.currentWeight += ep.weight
+			total += ep.weight
+			if best == nil || ep.currentWeight > best.currentWeight {
+				best = ep
+			}
+		}
+		best.currentWeight -= total
+		return best
+	default:
+		ep := candidates[b.next%len(candidates)]
+		b.next++
+		return ep
+	}
+}
+
+// probeLoop runs active health probes until the balancer is closed
+func (b *Balancer) probeLoop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	b.probeAll()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.probeAll()
+		}
+	}
+}
+
+// probeAll probes every endpoint concurrently
+func (b *Balancer) probeAll() {
+	var wg sync.WaitGroup
+	for _, ep := range b.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			healthy := b.probe(ep)
+
+			b.mu.Lock()
+			ep.probeHealthy = healthy
+			b.mu.Unlock()
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// probe reports whether the endpoint's health path answers with 2xx
+func (b *Balancer) probe(ep *endpoint) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.HealthTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.url+b.cfg.HealthPath, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := b.probeClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
@@ -0,0 +1,247 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCountingServer returns a server that counts requests and answers with status
+func newCountingServer(count *int32, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(status)
+			return
+		}
+		atomic.AddInt32(count, 1)
+		w.WriteHeader(status)
+	}))
+}
+
+func newBalancerTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestNewBalancerRequiresEndpoints(t *testing.T) {
+	_, err := httpclient.NewBalancer(nil, httpclient.BalancerConfig{})
+	require.Error(t, err)
+}
+
+func TestRoundRobinBalancing(t *testing.T) {
+	var countA, countB int32
+	serverA := newCountingServer(&countA, http.StatusOK)
+	defer serverA.Close()
+	serverB := newCountingServer(&countB, http.StatusOK)
+	defer serverB.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: serverA.URL},
+		{URL: serverB.URL},
+	}, httpclient.BalancerConfig{Policy: httpclient.RoundRobin})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+	for i := 0; i < 4; i++ {
+		_, err := client.Get(context.Background(), "/inventory", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&countA))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&countB))
+}
+
+func TestWeightedBalancing(t *testing.T) {
+	var countA, countB int32
+	serverA := newCountingServer(&countA, http.StatusOK)
+	defer serverA.Close()
+	serverB := newCountingServer(&countB, http.StatusOK)
+	defer serverB.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: serverA.URL, Weight: 3},
+		{URL: serverB.URL, Weight: 1},
+	}, httpclient.BalancerConfig{Policy: httpclient.Weighted})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+	for i := 0; i < 8; i++ {
+		_, err := client.Get(context.Background(), "/inventory", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(6), atomic.LoadInt32(&countA))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&countB))
+}
+
+func TestLeastInFlightBalancing(t *testing.T) {
+	release := make(chan struct{})
+	var countA, countB int32
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&countA, 1) == 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serverA.Close()
+	serverB := newCountingServer(&countB, http.StatusOK)
+	defer serverB.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: serverA.URL},
+		{URL: serverB.URL},
+	}, httpclient.BalancerConfig{Policy: httpclient.LeastInFlight})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+
+	// Occupy endpoint A with a request that does not complete
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Get(context.Background(), "/inventory", nil)
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&countA) == 1 }, time.Second, time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(context.Background(), "/inventory", nil)
+		require.NoError(t, err)
+	}
+	close(release)
+	<-done
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&countA))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&countB))
+}
+
+func TestFailoverOnRetry(t *testing.T) {
+	var count int32
+	healthy := newCountingServer(&count, http.StatusOK)
+	defer healthy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: down.URL},
+		{URL: healthy.URL},
+	}, httpclient.BalancerConfig{})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+
+	resp, err := client.Get(context.Background(), "/inventory", &httpclient.RequestOption{
+		RetryCount:    1,
+		RetryInterval: time.Millisecond,
+		MaxBodySize:   1024,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestFailoverPostOnlyBeforeConnecting(t *testing.T) {
+	var count int32
+	healthy := newCountingServer(&count, http.StatusOK)
+	defer healthy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: down.URL},
+		{URL: healthy.URL},
+	}, httpclient.BalancerConfig{})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+
+	// A refused connection never reached the endpoint, so a POST is safe to repeat
+	resp, err := client.Post(context.Background(), "/orders", []byte(`{}`), &httpclient.RequestOption{
+		RetryCount:    1,
+		RetryInterval: time.Millisecond,
+		MaxBodySize:   1024,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestPassiveOutlierEjection(t *testing.T) {
+	var countBad, countGood int32
+	bad := newCountingServer(&countBad, http.StatusInternalServerError)
+	defer bad.Close()
+	good := newCountingServer(&countGood, http.StatusOK)
+	defer good.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: bad.URL},
+		{URL: good.URL},
+	}, httpclient.BalancerConfig{
+		MaxFailures:  2,
+		EjectionTime: time.Minute,
+	})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+	for i := 0; i < 10; i++ {
+		_, err := client.Get(context.Background(), "/inventory", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&countBad))
+	assert.Equal(t, int32(8), atomic.LoadInt32(&countGood))
+
+	statuses := balancer.Endpoints()
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Healthy)
+	assert.True(t, statuses[1].Healthy)
+}
+
+func TestActiveHealthProbes(t *testing.T) {
+	var countBad, countGood int32
+	bad := newCountingServer(&countBad, http.StatusServiceUnavailable)
+	defer bad.Close()
+	good := newCountingServer(&countGood, http.StatusOK)
+	defer good.Close()
+
+	balancer, err := httpclient.NewBalancer([]httpclient.Endpoint{
+		{URL: bad.URL},
+		{URL: good.URL},
+	}, httpclient.BalancerConfig{
+		HealthPath:     "/healthz",
+		HealthInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer balancer.Close()
+
+	require.Eventually(t, func() bool {
+		return !balancer.Endpoints()[0].Healthy
+	}, time.Second, 5*time.Millisecond)
+
+	client := httpclient.NewBalancedClient(newBalancerTestConfig(), balancer)
+	for i := 0; i < 4; i++ {
+		_, err := client.Get(context.Background(), "/inventory", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&countBad))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&countGood))
+}
@@ -0,0 +1,278 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// bytesBody implements RequestBody over an in-memory buffer
+type bytesBody struct {
+	data        []byte
+	contentType string
+}
+
+// NewBytesBody creates a request body from a byte slice
+func NewBytesBody(data []byte, contentType string) RequestBody {
+	return &bytesBody{
+		data:        data,
+		contentType: contentType,
+	}
+}
+
+// NewFormBody creates an application/x-www-form-urlencoded request body
+func NewFormBody(values url.Values) RequestBody {
+	return &bytesBody{
+		data:        []byte(values.Encode()),
+		contentType: "application/x-www-form-urlencoded",
+	}
+}
+
+// ContentType implements RequestBody.ContentType
+func (b *bytesBody) ContentType() string {
+	return b.contentType
+}
+
+// Open implements RequestBody.Open
+func (b *bytesBody) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b.data)), nil
+}
+
+// Len returns the size of the body in bytes
+func (b *bytesBody) Len() int64 {
+	return int64(len(b.data))
+}
+
+// multipartPart represents a single field or file of a multipart body
+type multipartPart struct {
+	field    string
+	filename string
+	value    string
+	open     func() (io.ReadCloser, error)
+}
+
+// MultipartBuilder builds multipart/form-data request bodies
+type MultipartBuilder struct {
+	parts     []multipartPart
+	tempFiles []string
+	err       error
+}
+
+// NewMultipartBuilder creates a new multipart body builder
+func NewMultipartBuilder() *MultipartBuilder {
+	return &MultipartBuilder{}
+}
+
+// AddField adds a form field
+func (b *MultipartBuilder) AddField(name, value string) *MultipartBuilder {
+	b.parts = append(b.parts, multipartPart{
+		field: name,
+		value: value,
+	})
+	return b
+}
+
+// AddFile adds a file part that is re-opened from disk on every attempt
+func (b *MultipartBuilder) AddFile(field, path string) *MultipartBuilder {
+	return b.AddFileFunc(field, filepath.Base(path), func() (io.ReadCloser, error) {
+		return os.Open(path)
+	})
+}
+
+// AddFileFunc adds a file part whose content is obtained by calling open on every attempt
+func (b *MultipartBuilder) AddFileFunc(field, filename string, open func() (io.ReadCloser, error)) *MultipartBuilder {
+	b.parts = append(b.parts, multipartPart{
+		field:    field,
+		filename: filename,
+		open:     open,
+	})
+	return b
+}
+
+// AddReader adds a file part from a reader. The reader is consumed once and
+// buffered to a temporary file so that the body can be replayed on retries.
+func (b *MultipartBuilder) AddReader(field, filename string, r io.Reader) *MultipartBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	file, err := os.CreateTemp("", "multipart-*")
+	if err != nil {
+		b.err = &Error{Message: "failed to create temp file", Cause: err}
+		return b
+	}
+	b.tempFiles = append(b.tempFiles, file.Name())
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		b.err = &Error{Message: "failed to buffer multipart file", Cause: err}
+		return b
+	}
+
+	path := file.Name()
+	return b.AddFileFunc(field, filename, func() (io.ReadCloser, error) {
+		return os.Open(path)
+	})
+}
+
+// Build creates the multipart body. The returned body must be closed to
+// remove any temporary files created by AddReader.
+func (b *MultipartBuilder) Build() (*MultipartBody, error) {
+	body := &MultipartBody{
+		parts:     b.parts,
+		tempFiles: b.tempFiles,
+	}
+	if b.err != nil {
+		body.Close()
+		return nil, b.err
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		body.Close()
+		return nil, &Error{Message: "failed to generate multipart boundary", Cause: err}
+	}
+	body.boundary = boundary
+
+	return body, nil
+}
+
+// MultipartBody implements RequestBody for multipart/form-data content
+type MultipartBody struct {
+	boundary  string
+	parts     []multipartPart
+	tempFiles []string
+}
+
+// ContentType implements RequestBody.ContentType
+func (b *MultipartBody) ContentType() string {
+	return "multipart/form-data; boundary=" + b.boundary
+}
+
+// Open implements RequestBody.Open
+func (b *MultipartBody) Open() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(b.writeTo(pw))
+	}()
+	return pr, nil
+}
+
+// Close removes temporary files backing the body
+func (b *MultipartBody) Close() error {
+	var firstErr error
+	for _, path := range b.tempFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	b.tempFiles = nil
+	return firstErr
+}
+
+// writeTo encodes all parts to w
+func (b *MultipartBody) writeTo(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+
+	for _, part := range b.parts {
+		if part.open == nil {
+			if err := mw.WriteField(part.field, part.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := writeFilePart(mw, part); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// writeFilePart copies a single file part into the multipart writer
+func writeFilePart(mw *multipart.Writer, part multipartPart) error {
+	src, err := part.open()
+	if err != nil {
+		return fmt.Errorf("failed to open multipart file %s: %w", part.filename, err)
+	}
+	defer src.Close()
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(part.field), escapeQuotes(part.filename)))
+	header.Set("Content-Type", fileContentType(part.filename))
+
+	dst, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// setRequestBody attaches a replayable body to the request
+func setRequestBody(req *http.Request, body RequestBody) error {
+	rc, err := body.Open()
+	if err != nil {
+		return &Error{
+			Message: "failed to open request body",
+			Cause:   err,
+		}
+	}
+
+	req.Body = rc
+	req.GetBody = body.Open
+	req.ContentLength = -1
+	if sized, ok := body.(interface{ Len() int64 }); ok {
+		req.ContentLength = sized.Len()
+		if req.ContentLength == 0 {
+			rc.Close()
+			req.Body = http.NoBody
+		}
+	}
+
+	if contentType := body.ContentType(); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return nil
+}
+
+// fileContentType guesses the content type of a file from its extension
+func fileContentType(filename string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// randomBoundary generates a multipart boundary
+func randomBoundary() (string, error) {
+	var buf [30]byte
+	if _, err := io.ReadFull(rand.Reader, buf[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", buf[:]), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes quotes in Content-Disposition parameters
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,225 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "42", r.PostForm.Get("order_id"))
+		assert.Equal(t, "a b&c", r.PostForm.Get("note"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	body := httpclient.NewFormBody(url.Values{
+		"order_id": {"42"},
+		"note":     {"a b&c"},
+	})
+	resp, err := client.Do(context.Background(), http.MethodPost, "/form", body, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMultipartBody(t *testing.T) {
+	dir := t.TempDir()
+	invoicePath := filepath.Join(dir, "invoice.pdf")
+	require.NoError(t, os.WriteFile(invoicePath, []byte("%PDF-invoice"), 0644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1024))
+		assert.Equal(t, "42", r.FormValue("order_id"))
+
+		file, header, err := r.FormFile("invoice")
+		require.NoError(t, err)
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "invoice.pdf", header.Filename)
+		assert.Equal(t, "application/pdf", header.Header.Get("Content-Type"))
+		assert.Equal(t, []byte("%PDF-invoice"), data)
+
+		file, header, err = r.FormFile("label")
+		require.NoError(t, err)
+		defer file.Close()
+		data, _ = io.ReadAll(file)
+		assert.Equal(t, "label.zpl", header.Filename)
+		assert.Equal(t, []byte("^XA^XZ"), data)
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	body, err := httpclient.NewMultipartBuilder().
+		AddField("order_id", "42").
+		AddFile("invoice", invoicePath).
+		AddReader("label", "label.zpl", strings.NewReader("^XA^XZ")).
+		Build()
+	require.NoError(t, err)
+	defer body.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	resp, err := client.Do(context.Background(), http.MethodPost, "/upload", body, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestMultipartBodyReplay(t *testing.T) {
+	body, err := httpclient.NewMultipartBuilder().
+		AddField("order_id", "42").
+		AddReader("label", "label.zpl", strings.NewReader("^XA^XZ")).
+		Build()
+	require.NoError(t, err)
+
+	first, err := body.Open()
+	require.NoError(t, err)
+	firstData, err := io.ReadAll(first)
+	require.NoError(t, err)
+
+	second, err := body.Open()
+	require.NoError(t, err)
+	secondData, err := io.ReadAll(second)
+	require.NoError(t, err)
+
+	assert.Equal(t, firstData, secondData)
+	assert.Contains(t, string(firstData), "^XA^XZ")
+
+	require.NoError(t, body.Close())
+	_, err = io.ReadAll(mustOpen(t, body))
+	assert.Error(t, err)
+}
+
+func TestMultipartBodyRetry(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every attempt receives the full body
+		require.NoError(t, r.ParseMultipartForm(1024))
+		assert.Equal(t, "INV-1", r.FormValue("invoice"))
+		file, _, err := r.FormFile("label")
+		require.NoError(t, err)
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, []byte("^XA^XZ"), data)
+
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			// Drop the connection before responding to force a retry
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	body, err := httpclient.NewMultipartBuilder().
+		AddField("invoice", "INV-1").
+		AddReader("label", "label.zpl", strings.NewReader("^XA^XZ")).
+		Build()
+	require.NoError(t, err)
+	defer body.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	// The idempotency key makes the upload safe to send again
+	resp, err := client.Do(context.Background(), http.MethodPost, "/upload", body, &httpclient.RequestOption{
+		Headers:       map[string]string{"Idempotency-Key": "label-42"},
+		RetryCount:    1,
+		RetryInterval: time.Millisecond,
+		MaxBodySize:   1024,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+// failingAuth rejects every request
+type failingAuth struct{}
+
+func (failingAuth) Authenticate(ctx context.Context, req *http.Request) error {
+	return errors.New("credentials unavailable")
+}
+
+// trackedFile records whether it was closed
+type trackedFile struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (f *trackedFile) Close() error {
+	f.closed.Store(true)
+	return nil
+}
+
+func TestMultipartBodyReleasedOnFailure(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+
+	rejecting := func(req *http.Request, info *httpclient.AttemptInfo, next httpclient.Invoker) (*httpclient.Response, error) {
+		return nil, errors.New("rejected")
+	}
+	clients := map[string]httpclient.Client{
+		"auth":        httpclient.NewClient(cfg, "http://127.0.0.1:1", httpclient.WithAuth(failingAuth{})),
+		"interceptor": httpclient.NewClient(cfg, "http://127.0.0.1:1", httpclient.WithInterceptors(rejecting)),
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			file := &trackedFile{Reader: strings.NewReader(strings.Repeat("x", 1<<20))}
+			body, err := httpclient.NewMultipartBuilder().
+				AddFileFunc("label", "label.zpl", func() (io.ReadCloser, error) { return file, nil }).
+				Build()
+			require.NoError(t, err)
+			defer body.Close()
+
+			_, err = client.Do(context.Background(), http.MethodPost, "/upload", body, &httpclient.RequestOption{MaxBodySize: 1024})
+			require.Error(t, err)
+
+			// The goroutine encoding the body exits and closes the file
+			assert.Eventually(t, file.closed.Load, time.Second, time.Millisecond)
+		})
+	}
+}
+
+func mustOpen(t *testing.T, body httpclient.RequestBody) io.Reader {
+	rc, err := body.Open()
+	require.NoError(t, err)
+	return rc
+}
@@ -0,0 +1,341 @@
+package http
+
+import (
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"order-system/pkg/infra/concurrent"
+)
+
+// Cache caches GET responses according to their Cache-Control headers and
+// revalidates stale entries with If-None-Match and If-Modified-Since
+type Cache struct {
+	store  CacheStore
+	hits   *concurrent.Counter
+	misses *concurrent.Counter
+}
+
+// NewCache creates a response cache backed by store. A 1000-entry in-memory
+// LRU store is used if store is nil.
+func NewCache(store CacheStore) *Cache {
+	if store == nil {
+		store = NewLRUStore(1000)
+	}
+	return &Cache{
+		store:  store,
+		hits:   concurrent.NewCounter(0),
+		misses: concurrent.NewCounter(0),
+	}
+}
+
+// WithCache enables response caching for GET requests
+func WithCache(cache *Cache) ClientOption {
+	return func(c *defaultClient) {
+		c.cache = cache
+	}
+}
+
+// Stats returns cache statistics. Revalidated entries count as hits.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Value(),
+		Misses: c.misses.Value(),
+	}
+}
+
+// fetch serves the request from the cache or performs it with do.
+// authenticated reports whether the client adds credentials to the request;
+// responses to authenticated requests are stored only when they allow it, as
+// the cache may be shared by clients with different credentials.
+func (c *Cache) fetch(key string, authenticated bool, opt *RequestOption, do func(*RequestOption) (*Response, error)) (*Response, error) {
+	if hasDirective(headerValue(opt.Headers, "Cache-Control"), "no-store") {
+		return do(opt)
+	}
+	authenticated = authenticated || headerValue(opt.Headers, "Authorization") != ""
+
+	now := time.Now()
+	entry, ok := c.store.Get(key)
+	if ok && !entry.matches(opt.Headers) {
+		// Stored for a request with other values of the headers it varies on
+		ok = false
+	}
+	if ok && now.Before(entry.Expires) {
+		c.hits.Increment()
+		return cloneResponse(entry.Response), nil
+	}
+
+	reqOpt := opt
+	if ok && (entry.ETag != "" || entry.LastModified != "") {
+		reqOpt = withConditionalHeaders(opt, entry)
+	}
+
+	resp, err := do(reqOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && reqOpt != opt && resp.StatusCode == http.StatusNotModified {
+		c.hits.Increment()
+		headers := resp.Headers
+		if !hasFreshnessInfo(headers) {
+			headers = entry.Response.Headers
+		}
+		if expires, storable := freshness(headers, time.Now()); storable {
+			c.store.Set(key, &CacheEntry{
+				Response:     entry.Response,
+				ETag:         entry.ETag,
+				LastModified: entry.LastModified,
+				Expires:      expires,
+				Vary:         entry.Vary,
+			})
+		}
+		return cloneResponse(entry.Response), nil
+	}
+
+	c.misses.Increment()
+	c.save(key, opt, authenticated, resp)
+	return resp, nil
+}
+
+// save stores a response if it is cacheable
+func (c *Cache) save(key string, opt *RequestOption, authenticated bool, resp *Response) {
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	vary, varyOK := varyValues(resp.Headers, opt.Headers)
+	if !varyOK || (authenticated && !sharedWithAuthorization(resp.Headers)) {
+		c.store.Delete(key)
+		return
+	}
+
+	expires, storable := freshness(resp.Headers, time.Now())
+	etag := http.Header(resp.Headers).Get("ETag")
+	lastModified := http.Header(resp.Headers).Get("Last-Modified")
+	if !storable || (!expires.After(time.Now()) && etag == "" && lastModified == "") {
+		c.store.Delete(key)
+		return
+	}
+
+	c.store.Set(key, &CacheEntry{
+		Response:     cloneResponse(resp),
+		ETag:         etag,
+		LastModified: lastModified,
+		Expires:      expires,
+		Vary:         vary,
+	})
+}
+
+// varyValues returns the values of the request headers named by the Vary
+// header of a response, or false if the response varies on "*"
+func varyValues(respHeaders map[string][]string, reqHeaders map[string]string) (map[string]string, bool) {
+	var values map[string]string
+	for _, field := range http.Header(respHeaders).Values("Vary") {
+		for _, name := range strings.Split(field, ",") {
+			name = strings.TrimSpace(name)
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[http.CanonicalHeaderKey(name)] = headerValue(reqHeaders, name)
+		}
+	}
+	return values, true
+}
+
+// sharedWithAuthorization reports whether a response to an authenticated
+// request may be stored, following RFC 9111 section 3.5
+func sharedWithAuthorization(headers map[string][]string) bool {
+	cacheControl := strings.Join(http.Header(headers).Values("Cache-Control"), ",")
+	return hasDirective(cacheControl, "public") ||
+		hasDirective(cacheControl, "s-maxage") ||
+		hasDirective(cacheControl, "must-revalidate")
+}
+
+// matches reports whether the entry was stored for a request with the same
+// values of the headers its response varies on
+func (e *CacheEntry) matches(headers map[string]string) bool {
+	for name, value := range e.Vary {
+		if headerValue(headers, name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// headerValue returns the value of a request header, matching its name
+// case-insensitively
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// freshness computes when a response expires and whether it may be stored
+func freshness(headers map[string][]string, now time.Time) (time.Time, bool) {
+	h := http.Header(headers)
+	cacheControl := strings.Join(h.Values("Cache-Control"), ",")
+
+	if hasDirective(cacheControl, "no-store") {
+		return time.Time{}, false
+	}
+	if hasDirective(cacheControl, "no-cache") {
+		return now, true
+	}
+
+	var age time.Duration
+	if seconds, err := strconv.ParseInt(h.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		age = time.Duration(seconds) * time.Second
+	}
+
+	if maxAge, ok := directiveValue(cacheControl, "max-age"); ok {
+		seconds, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil || seconds < 0 {
+			return now, true
+		}
+		return now.Add(time.Duration(seconds)*time.Second - age), true
+	}
+
+	if expires := h.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			return t, true
+		}
+		return now, true
+	}
+
+	// Without freshness information the response must be revalidated
+	return now, true
+}
+
+// hasFreshnessInfo reports whether headers define an explicit lifetime
+func hasFreshnessInfo(headers map[string][]string) bool {
+	h := http.Header(headers)
+	return h.Get("Cache-Control") != "" || h.Get("Expires") != ""
+}
+
+// hasDirective reports whether a Cache-Control value contains directive
+func hasDirective(cacheControl, directive string) bool {
+	for _, part := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// directiveValue returns the value of a Cache-Control directive
+func directiveValue(cacheControl, directive string) (string, bool) {
+	for _, part := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found && strings.EqualFold(name, directive) {
+			return strings.Trim(value, `"`), true
+		}
+	}
+	return "", false
+}
+
+// withConditionalHeaders returns a copy of opt with revalidation headers
+func withConditionalHeaders(opt *RequestOption, entry *CacheEntry) *RequestOption {
+	conditional := *opt
+	conditional.Headers = make(map[string]string, len(opt.Headers)+2)
+	for k, v := range opt.Headers {
+		conditional.Headers[k] = v
+	}
+	if entry.ETag != "" {
+		conditional.Headers["If-None-Match"] = entry.ETag
+	}
+	if entry.LastModified != "" {
+		conditional.Headers["If-Modified-Since"] = entry.LastModified
+	}
+	return &conditional
+}
+
+// cloneResponse returns a deep copy of resp
+func cloneResponse(resp *Response) *Response {
+	clone := *resp
+	clone.Body = append([]byte(nil), resp.Body...)
+	clone.Headers = http.Header(resp.Headers).Clone()
+	return &clone
+}
+
+// lruStore implements CacheStore with a fixed-size least-recently-used cache
+type lruStore struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+// lruItem represents an element of the LRU list
+type lruItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewLRUStore creates an in-memory cache store holding up to capacity entries
+func NewLRUStore(capacity int) CacheStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &lruStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get implements CacheStore.Get
+func (s *lruStore) Get(key string) (*CacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*lruItem).entry, true
+}
+
+// Set implements CacheStore.Set
+func (s *lruStore) Set(key string, entry *CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.order.PushFront(&lruItem{key: key, entry: entry})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Delete implements CacheStore.Delete
+func (s *lruStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.order.Remove(elem)
+		delete(s.items, key)
+	}
+}
@@ -0,0 +1,271 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("catalog"))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(context.Background(), "/catalog", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("catalog"), resp.Body)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, httpclient.CacheStats{Hits: 2, Misses: 1}, cache.Stats())
+}
+
+func TestCacheNoStore(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "no-store, max-age=60")
+		w.Write([]byte("tax-rates"))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), "/tax-rates", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, httpclient.CacheStats{Misses: 2}, cache.Stats())
+}
+
+func TestCacheRevalidateETag(t *testing.T) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte("catalog-v1"))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(context.Background(), "/catalog", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []byte("catalog-v1"), resp.Body)
+	}
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+	assert.Equal(t, httpclient.CacheStats{Hits: 2, Misses: 1}, cache.Stats())
+}
+
+func TestCacheRevalidateLastModified(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("rates"))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(context.Background(), "/rates", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("rates"), resp.Body)
+	}
+
+	assert.Equal(t, httpclient.CacheStats{Hits: 1, Misses: 1}, cache.Stats())
+}
+
+func TestCacheRequestNoStore(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("stock"))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	// Header names are case-insensitive
+	opt := &httpclient.RequestOption{
+		Timeout:     time.Second,
+		MaxBodySize: 1024,
+		Headers:     map[string]string{"cache-control": "no-store"},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), "/stock", opt)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, httpclient.CacheStats{}, cache.Stats())
+}
+
+func TestCacheVary(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.URL.Path == "/search" {
+			w.Header().Set("Vary", "*")
+		} else {
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		w.Write([]byte("catalog-" + r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	get := func(lang string) string {
+		resp, err := client.Get(context.Background(), "/catalog", &httpclient.RequestOption{
+			Timeout:     time.Second,
+			MaxBodySize: 1024,
+			Headers:     map[string]string{"accept-language": lang},
+		})
+		require.NoError(t, err)
+		return string(resp.Body)
+	}
+
+	assert.Equal(t, "catalog-en", get("en"))
+	assert.Equal(t, "catalog-en", get("en"))
+	assert.Equal(t, "catalog-de", get("de"))
+	assert.Equal(t, "catalog-de", get("de"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// A response varying on every header is never stored
+	atomic.StoreInt32(&requests, 0)
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), "/search", nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestCacheAuthenticated(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/public" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		w.Write([]byte("account"))
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL,
+		httpclient.WithCache(cache),
+		httpclient.WithAuth(httpclient.NewBearerAuth("user-token")))
+
+	// The cache may be shared by clients with other credentials, so only
+	// responses marked as shareable are stored
+	for _, path := range []string{"/account", "/account", "/public", "/public"} {
+		_, err := client.Get(context.Background(), path, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// Credentials passed per request count as well
+	plain := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+	for i := 0; i < 2; i++ {
+		_, err := plain.Get(context.Background(), "/profile", &httpclient.RequestOption{
+			Timeout:     time.Second,
+			MaxBodySize: 1024,
+			Headers:     map[string]string{"Authorization": "Bearer other-token"},
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+}
+
+func TestCacheIgnoresPost(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cache := httpclient.NewCache(nil)
+	client := httpclient.NewClient(newCacheTestConfig(), server.URL, httpclient.WithCache(cache))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Post(context.Background(), "/orders", []byte("{}"), nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, httpclient.CacheStats{}, cache.Stats())
+}
+
+func TestLRUStoreEviction(t *testing.T) {
+	store := httpclient.NewLRUStore(2)
+	store.Set("a", &httpclient.CacheEntry{})
+	store.Set("b", &httpclient.CacheEntry{})
+
+	_, ok := store.Get("a")
+	require.True(t, ok)
+
+	store.Set("c", &httpclient.CacheEntry{})
+
+	_, ok = store.Get("b")
+	assert.False(t, ok)
+	_, ok = store.Get("a")
+	assert.True(t, ok)
+	_, ok = store.Get("c")
+	assert.True(t, ok)
+
+	store.Delete("a")
+	_, ok = store.Get("a")
+	assert.False(t, ok)
+}
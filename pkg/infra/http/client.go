@@ -1,13 +1,14 @@
 package http
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"order-system/pkg/infra/concurrent"
 	"order-system/pkg/infra/config"
 )
 
@@ -15,26 +16,86 @@ import (
 type defaultClient struct {
 	client  *http.Client
 	config  *config.Config
+	name    string
 	baseURL string
+	initErr error
+	auth    AuthProvider
+
+	interceptors []Interceptor
+	middlewares  []TransportMiddleware
+	balancer     *Balancer
+	cache        *Cache
+
+	latency   *latencyTracker
+	hedged    *concurrent.Counter
+	hedgeWins *concurrent.Counter
+}
+
+// ClientOption configures optional client behavior
+type ClientOption func(*defaultClient)
+
+// WithName names the client, selecting its transport overrides in
+// config.Config.HTTPClient.Clients
+func WithName(name string) ClientOption {
+	return func(c *defaultClient) {
+		c.name = name
+	}
+}
+
+// WithTransportMiddleware wraps the client's transport. The first middleware
+// is the outermost one.
+func WithTransportMiddleware(middlewares ...TransportMiddleware) ClientOption {
+	return func(c *defaultClient) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithAuth sets the provider used to authenticate every request
+func WithAuth(provider AuthProvider) ClientOption {
+	return func(c *defaultClient) {
+		c.auth = provider
+	}
 }
 
 // NewClient creates a new HTTP client
-func NewClient(cfg *config.Config, baseURL string) Client {
-	client := &http.Client{
-		Timeout: cfg.HTTP.RequestTimeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 100,
-			MaxConnsPerHost:     100,
-			IdleConnTimeout:     90 * time.Second,
-		},
+func NewClient(cfg *config.Config, baseURL string, opts ...ClientOption) Client {
+	c := &defaultClient{
+		config:    cfg,
+		baseURL:   baseURL,
+		latency:   newLatencyTracker(latencySamples),
+		hedged:    concurrent.NewCounter(0),
+		hedgeWins: concurrent.NewCounter(0),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	return &defaultClient{
-		client:  client,
-		config:  cfg,
-		baseURL: baseURL,
+	// Configuration errors are reported by every request
+	transport, err := NewTransport(cfg.ClientTransport(c.name))
+	if err != nil {
+		c.initErr = &Error{
+			Message: "invalid transport configuration",
+			Cause:   err,
+		}
+		transport, _ = NewTransport(config.TransportConfig{})
+	}
+
+	// Fault injection sits closest to the network so that every layer sees the faults
+	roundTripper := NewFaultMiddleware(cfg.HTTPClient.Faults, c.name)(transport)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		roundTripper = c.middlewares[i](roundTripper)
+	}
+	c.client = &http.Client{
+		Timeout:   cfg.HTTP.RequestTimeout,
+		Transport: roundTripper,
 	}
+
+	return c
+}
+
+// Do performs a request with a replayable body
+func (c *defaultClient) Do(ctx context.Context, method, url string, body RequestBody, opt *RequestOption) (*Response, error) {
+	return c.do(ctx, method, url, body, opt)
 }
 
 // Get performs a GET request
@@ -44,12 +105,12 @@ func (c *defaultClient) Get(ctx context.Context, url string, opt *RequestOption)
 
 // Post performs a POST request
 func (c *defaultClient) Post(ctx context.Context, url string, body []byte, opt *RequestOption) (*Response, error) {
-	return c.do(ctx, http.MethodPost, url, body, opt)
+	return c.do(ctx, http.MethodPost, url, NewBytesBody(body, ""), opt)
 }
 
 // Put performs a PUT request
 func (c *defaultClient) Put(ctx context.Context, url string, body []byte, opt *RequestOption) (*Response, error) {
-	return c.do(ctx, http.MethodPut, url, body, opt)
+	return c.do(ctx, http.MethodPut, url, NewBytesBody(body, ""), opt)
 }
 
 // Delete performs a DELETE request
@@ -58,7 +119,7 @@ func (c *defaultClient) Delete(ctx context.Context, url string, opt *RequestOpti
 }
 
 // do performs the HTTP request with retries
-func (c *defaultClient) do(ctx context.Context, method, url string, body []byte, opt *RequestOption) (*Response, error) {
+func (c *defaultClient) do(ctx context.Context, method, url string, body RequestBody, opt *RequestOption) (*Response, error) {
 	if opt == nil {
 		opt = &RequestOption{
 			Timeout:       c.config.HTTP.RequestTimeout,
@@ -68,17 +129,42 @@ func (c *defaultClient) do(ctx context.Context, method, url string, body []byte,
 		}
 	}
 
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+
+	if c.cache != nil && method == http.MethodGet {
+		return c.cache.fetch(c.baseURL+url, c.auth != nil, opt, func(opt *RequestOption) (*Response, error) {
+			return c.doWithRetry(ctx, method, url, body, opt)
+		})
+	}
+	return c.doWithRetry(ctx, method, url, body, opt)
+}
+
+// doWithRetry performs the request, retrying failed attempts
+func (c *defaultClient) doWithRetry(ctx context.Context, method, url string, body RequestBody, opt *RequestOption) (*Response, error) {
 	var resp *Response
 	var lastErr error
+	refreshed := false
+	tried := newEndpointSet()
 
 	for i := 0; i <= opt.RetryCount; i++ {
-		resp, lastErr = c.doRequest(ctx, method, url, body, opt)
+		attemptCtx := context.WithValue(ctx, attemptKey{}, i+1)
+		resp, lastErr = c.doAttempt(attemptCtx, method, url, body, opt, tried)
 		if lastErr == nil {
+			// Renew rejected credentials once without consuming a retry
+			if resp.StatusCode == http.StatusUnauthorized && !refreshed {
+				refreshed = true
+				if c.refreshAuth(ctx) {
+					i--
+					continue
+				}
+			}
 			return resp, nil
 		}
 
 		// Check if we should retry
-		if !c.shouldRetry(lastErr) || i == opt.RetryCount {
+		if !c.shouldRetry(lastErr, method, opt) || i == opt.RetryCount {
 			break
 		}
 
@@ -94,10 +180,35 @@ func (c *defaultClient) do(ctx context.Context, method, url string, body []byte,
 	return nil, lastErr
 }
 
+// doAttempt performs a single attempt, hedging it when enabled
+func (c *defaultClient) doAttempt(ctx context.Context, method, url string, body RequestBody, opt *RequestOption, tried *endpointSet) (*Response, error) {
+	if delay, ok := c.hedgeDelay(method, opt); ok {
+		return c.doHedged(ctx, method, url, body, opt, tried, delay)
+	}
+	return c.send(ctx, method, url, body, opt, tried)
+}
+
+// send performs a single HTTP request against the base URL or, when load
+// balancing, an endpoint not yet tried for this request
+func (c *defaultClient) send(ctx context.Context, method, url string, body RequestBody, opt *RequestOption, tried *endpointSet) (*Response, error) {
+	if c.balancer == nil {
+		return c.doRequest(ctx, method, c.baseURL+url, body, opt)
+	}
+
+	ep := c.balancer.acquire(tried)
+	defer c.balancer.release(ep)
+
+	resp, err := c.doRequest(ctx, method, ep.url+url, body, opt)
+	// Cancelled attempts, such as hedging losers, say nothing about endpoint health
+	if ctx.Err() == nil {
+		c.balancer.report(ep, err == nil && resp.StatusCode < 500)
+	}
+	return resp, err
+}
+
 // doRequest performs a single HTTP request
-func (c *defaultClient) doRequest(ctx context.Context, method, url string, body []byte, opt *RequestOption) (*Response, error) {
-	fullURL := c.baseURL + url
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(body))
+func (c *defaultClient) doRequest(ctx context.Context, method, fullURL string, body RequestBody, opt *RequestOption) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, nil)
 	if err != nil {
 		return nil, &Error{
 			Message: "failed to create request",
@@ -105,11 +216,54 @@ func (c *defaultClient) doRequest(ctx context.Context, method, url string, body
 		}
 	}
 
+	original := body
+	if body != nil {
+		if shouldCompress(body, opt.CompressThreshold) {
+			body = newGzipBody(body)
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		if err := setRequestBody(req, body); err != nil {
+			return nil, err
+		}
+		// The transport closes the body once sent, but the request may fail
+		// before reaching it; closing stops the encoding goroutines and files
+		defer req.Body.Close()
+	}
+
+	// Negotiate response compression
+	if opt.DisableCompression {
+		req.Header.Set("Accept-Encoding", "identity")
+	} else {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
 	// Add headers
 	for k, v := range opt.Headers {
 		req.Header.Set(k, v)
 	}
 
+	if c.auth != nil {
+		if err := c.auth.Authenticate(ctx, req); err != nil {
+			return nil, &Error{
+				Message: "failed to authenticate request",
+				Cause:   err,
+			}
+		}
+	}
+
+	info := &AttemptInfo{
+		Client:  c.name,
+		Route:   opt.Route,
+		Attempt: attemptFromContext(ctx),
+		Body:    original,
+	}
+	return c.intercept(req, info, func(req *http.Request) (*Response, error) {
+		return c.execute(req, opt)
+	})
+}
+
+// execute sends the request and reads the response body
+func (c *defaultClient) execute(req *http.Request, opt *RequestOption) (*Response, error) {
 	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -128,8 +282,18 @@ func (c *defaultClient) doRequest(ctx context.Context, method, url string, body
 		}
 	}
 
-	// Read response body
-	respBody, err := io.ReadAll(io.LimitReader(resp.Body, opt.MaxBodySize))
+	// Read response body, applying the size limit after decompression
+	reader, err := decodeBody(resp)
+	if err != nil {
+		return nil, &Error{
+			StatusCode: resp.StatusCode,
+			Message:    "failed to decode response body",
+			Cause:      err,
+		}
+	}
+	defer reader.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(reader, opt.MaxBodySize+1))
 	if err != nil {
 		return nil, &Error{
 			StatusCode: resp.StatusCode,
@@ -137,30 +301,82 @@ func (c *defaultClient) doRequest(ctx context.Context, method, url string, body
 			Cause:      err,
 		}
 	}
+	if int64(len(respBody)) > opt.MaxBodySize {
+		return nil, &Error{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("response body too large: exceeds %d bytes", opt.MaxBodySize),
+		}
+	}
+
+	duration := time.Since(start)
+	c.latency.Observe(duration)
 
 	return &Response{
 		StatusCode: resp.StatusCode,
 		Body:       respBody,
 		Headers:    resp.Header,
-		Duration:   time.Since(start),
+		Duration:   duration,
 	}, nil
 }
 
+// Stats returns client statistics
+func (c *defaultClient) Stats() Stats {
+	return Stats{
+		HedgedRequests: c.hedged.Value(),
+		HedgeWins:      c.hedgeWins.Value(),
+	}
+}
+
+// refreshAuth renews the credentials of a refreshable auth provider
+func (c *defaultClient) refreshAuth(ctx context.Context) bool {
+	refresher, ok := c.auth.(Refresher)
+	if !ok {
+		return false
+	}
+	return refresher.Refresh(ctx) == nil
+}
+
 // shouldRetry determines if a request should be retried
-func (c *defaultClient) shouldRetry(err error) bool {
+func (c *defaultClient) shouldRetry(err error, method string, opt *RequestOption) bool {
 	if err == nil {
 		return false
 	}
 
 	// Check if it's a network error
-	if _, ok := err.(*Error); !ok {
+	httpErr, ok := err.(*Error)
+	if !ok {
 		return true
 	}
+	// The server may have processed a request whose connection failed, so
+	// only balanced requests that never connected fail over, and other
+	// requests are repeated only when that is safe
+	if httpErr.StatusCode == 0 && isNetworkError(httpErr.Cause) {
+		if c.balancer != nil && isDialError(httpErr.Cause) {
+			return true
+		}
+		return isReplayable(method, opt.Headers)
+	}
 
 	// Check if it's a server error (5xx)
-	if httpErr, ok := err.(*Error); ok && httpErr.StatusCode >= 500 {
+	if httpErr.StatusCode >= 500 {
 		return true
 	}
 
 	return false
 }
+
+// isReplayable reports whether a request may be sent again after its
+// connection failed: idempotent methods and requests carrying an
+// idempotency key, as net/http decides for its own retries
+func isReplayable(method string, headers map[string]string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	for k := range headers {
+		if strings.EqualFold(k, "Idempotency-Key") || strings.EqualFold(k, "X-Idempotency-Key") {
+			return true
+		}
+	}
+	return false
+}
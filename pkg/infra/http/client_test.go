@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -161,6 +162,47 @@ func TestRequestWithNetworkError(t *testing.T) {
 	assert.Contains(t, err.Error(), "request failed")
 }
 
+func TestConnectionFailureRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The request is processed, but the connection drops before the response
+		atomic.AddInt32(&requests, 1)
+		io.ReadAll(r.Body)
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	client := httpclient.NewClient(cfg, server.URL)
+
+	tests := []struct {
+		name     string
+		method   string
+		headers  map[string]string
+		attempts int32
+	}{
+		{name: "POST is not repeated", method: http.MethodPost, attempts: 1},
+		{name: "POST with idempotency key", method: http.MethodPost, headers: map[string]string{"idempotency-key": "order-42"}, attempts: 3},
+		{name: "GET", method: http.MethodGet, attempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+			_, err := client.Do(context.Background(), tt.method, "/orders", httpclient.NewBytesBody([]byte(`{}`), "application/json"), &httpclient.RequestOption{
+				Headers:       tt.headers,
+				RetryCount:    2,
+				RetryInterval: time.Millisecond,
+				MaxBodySize:   1024,
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.attempts, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
 func TestRequestWithInvalidURL(t *testing.T) {
 	cfg := &config.Config{}
 	cfg.HTTP.RequestTimeout = 30 * time.Second
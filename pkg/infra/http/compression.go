@@ -0,0 +1,113 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptEncoding lists the response encodings the client can decode
+const acceptEncoding = "gzip, deflate"
+
+// gzipBody implements RequestBody by compressing another body on the fly
+type gzipBody struct {
+	body RequestBody
+}
+
+// newGzipBody wraps body with gzip compression
+func newGzipBody(body RequestBody) RequestBody {
+	return &gzipBody{body: body}
+}
+
+// ContentType implements RequestBody.ContentType
+func (b *gzipBody) ContentType() string {
+	return b.body.ContentType()
+}
+
+// Open implements RequestBody.Open
+func (b *gzipBody) Open() (io.ReadCloser, error) {
+	src, err := b.body.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer src.Close()
+		gw := gzip.NewWriter(pw)
+		if _, err := io.Copy(gw, src); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(gw.Close())
+	}()
+	return pr, nil
+}
+
+// shouldCompress reports whether a request body exceeds the compression threshold.
+// Bodies of unknown size are compressed whenever a threshold is set.
+func shouldCompress(body RequestBody, threshold int64) bool {
+	if threshold <= 0 {
+		return false
+	}
+	if sized, ok := body.(interface{ Len() int64 }); ok {
+		return sized.Len() > threshold
+	}
+	return true
+}
+
+// decodeBody returns a reader that decompresses the response body according
+// to its Content-Encoding. The encoding headers are removed once decoded.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	var reader io.ReadCloser
+	switch encoding {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		gr, err := gzip.NewReader(resp.Body)
+		if err == io.EOF {
+			// Empty bodies, e.g. for HEAD or 204 responses, carry no gzip header
+			reader = io.NopCloser(resp.Body)
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		reader = gr
+	case "deflate":
+		dr, err := newDeflateReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		reader = dr
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	return reader, nil
+}
+
+// newDeflateReader decodes a deflate body. Servers disagree on whether
+// "deflate" means zlib-wrapped or raw deflate data, so both are accepted.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	// A zlib stream starts with a CMF/FLG pair whose value is a multiple of 31
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,139 @@
+package http_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipResponse(t *testing.T) {
+	payload := strings.Repeat(`{"order_id":42}`, 100)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Accept-Encoding"), "gzip")
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(payload))
+		gw.Close()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	resp, err := client.Get(context.Background(), "/export", &httpclient.RequestOption{
+		MaxBodySize: 4096,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte(payload), resp.Body)
+	assert.Empty(t, resp.Headers["Content-Encoding"])
+}
+
+func TestDeflateResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "deflate")
+		zw := zlib.NewWriter(w)
+		zw.Write([]byte("deflated"))
+		zw.Close()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	resp, err := client.Get(context.Background(), "/export", &httpclient.RequestOption{
+		MaxBodySize: 1024,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte("deflated"), resp.Body)
+}
+
+func TestGzipResponseTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write(make([]byte, 1<<20))
+		gw.Close()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	_, err := client.Get(context.Background(), "/export", &httpclient.RequestOption{
+		MaxBodySize: 1024,
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "response body too large")
+}
+
+func TestCompressedRequest(t *testing.T) {
+	payload := strings.Repeat("order-line,", 200)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		gr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(gr)
+		assert.Equal(t, payload, string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	resp, err := client.Post(context.Background(), "/import", []byte(payload), &httpclient.RequestOption{
+		MaxBodySize:       1024,
+		CompressThreshold: 512,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestSmallRequestNotCompressed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Content-Encoding"))
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, bytes.Equal([]byte("small"), body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+
+	client := httpclient.NewClient(cfg, server.URL)
+
+	_, err := client.Post(context.Background(), "/import", []byte("small"), &httpclient.RequestOption{
+		MaxBodySize:       1024,
+		CompressThreshold: 512,
+	})
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"order-system/pkg/infra/config"
+)
+
+// faultTransport injects latency, synthetic statuses, connection resets and
+// truncated bodies into requests matching the configured rules
+type faultTransport struct {
+	rules  []config.FaultRule
+	client string
+	next   http.RoundTripper
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewFaultMiddleware creates a transport middleware that injects faults into
+// the requests of the named client according to cfg. NewClient installs it
+// automatically when cfg.HTTPClient.Faults is enabled.
+func NewFaultMiddleware(cfg config.FaultConfig, client string) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if !cfg.Enabled || len(cfg.Rules) == 0 {
+			return next
+		}
+		return &faultTransport{
+			rules:  cfg.Rules,
+			client: client,
+			next:   next,
+			rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		}
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rule, ok := t.match(req)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	if rule.Latency > 0 && t.roll(rule.LatencyProbability) {
+		timer := time.NewTimer(rule.Latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			closeRequestBody(req)
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if t.roll(rule.ResetProbability) {
+		closeRequestBody(req)
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	}
+
+	if t.roll(rule.StatusProbability) {
+		closeRequestBody(req)
+		body := []byte("fault injected")
+		return &http.Response{
+			Status:        http.StatusText(rule.StatusCode),
+			StatusCode:    rule.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"X-Fault-Injected": {"status"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.roll(rule.TruncateProbability) {
+		resp.Body = &truncatedBody{
+			body:      resp.Body,
+			remaining: rule.TruncateAfter,
+		}
+		resp.Header.Set("X-Fault-Injected", "truncate")
+	}
+	return resp, nil
+}
+
+// match returns the first rule matching the request
+func (t *faultTransport) match(req *http.Request) (config.FaultRule, bool) {
+	for _, rule := range t.rules {
+		if rule.Client != "" && rule.Client != t.client {
+			continue
+		}
+		if rule.Method != "" && !strings.EqualFold(rule.Method, req.Method) {
+			continue
+		}
+		if rule.Route != "" {
+			if matched, _ := path.Match(rule.Route, req.URL.Path); !matched {
+				continue
+			}
+		}
+		return rule, true
+	}
+	return config.FaultRule{}, false
+}
+
+// roll reports whether an event with probability p happens
+func (t *faultTransport) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rnd.Float64() < p
+}
+
+// closeRequestBody closes the body of a request that will not be sent
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// truncatedBody fails with io.ErrUnexpectedEOF after a number of bytes
+type truncatedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+// Read implements io.Reader
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *truncatedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,145 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFaultTestServer(requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+}
+
+func newFaultTestConfig(rules ...config.FaultRule) *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	cfg.HTTPClient.Faults.Enabled = true
+	cfg.HTTPClient.Faults.Rules = rules
+	return cfg
+}
+
+var faultNoRetry = &httpclient.RequestOption{MaxBodySize: 1024}
+
+func TestFaultSyntheticStatus(t *testing.T) {
+	var requests int32
+	server := newFaultTestServer(&requests)
+	defer server.Close()
+
+	cfg := newFaultTestConfig(config.FaultRule{
+		Route:             "/orders/*",
+		StatusProbability: 1,
+		StatusCode:        http.StatusServiceUnavailable,
+	})
+	client := httpclient.NewClient(cfg, server.URL)
+
+	resp, err := client.Get(context.Background(), "/orders/42", faultNoRetry)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	// Other routes are not affected
+	resp, err = client.Get(context.Background(), "/inventory/42", faultNoRetry)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestFaultConnectionReset(t *testing.T) {
+	var requests int32
+	server := newFaultTestServer(&requests)
+	defer server.Close()
+
+	cfg := newFaultTestConfig(config.FaultRule{ResetProbability: 1})
+	client := httpclient.NewClient(cfg, server.URL)
+
+	_, err := client.Get(context.Background(), "/orders/42", &httpclient.RequestOption{
+		RetryCount:    2,
+		RetryInterval: time.Millisecond,
+		MaxBodySize:   1024,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection reset")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+}
+
+func TestFaultLatency(t *testing.T) {
+	var requests int32
+	server := newFaultTestServer(&requests)
+	defer server.Close()
+
+	cfg := newFaultTestConfig(config.FaultRule{
+		Method:             http.MethodGet,
+		LatencyProbability: 1,
+		Latency:            50 * time.Millisecond,
+	})
+	client := httpclient.NewClient(cfg, server.URL)
+
+	start := time.Now()
+	_, err := client.Get(context.Background(), "/orders/42", faultNoRetry)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestFaultTruncatedBody(t *testing.T) {
+	var requests int32
+	server := newFaultTestServer(&requests)
+	defer server.Close()
+
+	cfg := newFaultTestConfig(config.FaultRule{
+		TruncateProbability: 1,
+		TruncateAfter:       10,
+	})
+	client := httpclient.NewClient(cfg, server.URL)
+
+	_, err := client.Get(context.Background(), "/orders/42", faultNoRetry)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read response body")
+}
+
+func TestFaultRuleForOtherClient(t *testing.T) {
+	var requests int32
+	server := newFaultTestServer(&requests)
+	defer server.Close()
+
+	cfg := newFaultTestConfig(config.FaultRule{
+		Client:            "payments",
+		StatusProbability: 1,
+		StatusCode:        http.StatusInternalServerError,
+	})
+
+	resp, err := httpclient.NewClient(cfg, server.URL, httpclient.WithName("inventory")).
+		Get(context.Background(), "/orders/42", faultNoRetry)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = httpclient.NewClient(cfg, server.URL, httpclient.WithName("payments")).
+		Get(context.Background(), "/orders/42", faultNoRetry)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestFaultsDisabled(t *testing.T) {
+	var requests int32
+	server := newFaultTestServer(&requests)
+	defer server.Close()
+
+	cfg := newFaultTestConfig(config.FaultRule{ResetProbability: 1})
+	cfg.HTTPClient.Faults.Enabled = false
+
+	_, err := httpclient.NewClient(cfg, server.URL).Get(context.Background(), "/orders/42", faultNoRetry)
+	require.NoError(t, err)
+}
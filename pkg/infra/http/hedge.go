@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencySamples is the number of recent request durations kept per client
+	latencySamples = 1000
+	// minHedgeSamples is the number of samples required before a percentile is used
+	minHedgeSamples = 20
+)
+
+// latencyTracker keeps a ring buffer of recent request durations
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// newLatencyTracker creates a tracker holding up to size samples
+func newLatencyTracker(size int) *latencyTracker {
+	return &latencyTracker{
+		samples: make([]time.Duration, size),
+	}
+}
+
+// Observe records a request duration
+func (t *latencyTracker) Observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % len(t.samples)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Percentile returns the latency at percentile p (0-1) and whether enough
+// samples were recorded to compute it
+func (t *latencyTracker) Percentile(p float64) (time.Duration, bool) {
+	t.mu.Lock()
+	n := t.next
+	if t.full {
+		n = len(t.samples)
+	}
+	if n < minHedgeSamples {
+		t.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, t.samples[:n])
+	t.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(p * float64(n-1))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= n {
+		idx = n - 1
+	}
+	return sorted[idx], true
+}
+
+// hedgeDelay returns the delay after which a hedge attempt should be sent.
+// Only idempotent GET requests are hedged.
+func (c *defaultClient) hedgeDelay(method string, opt *RequestOption) (time.Duration, bool) {
+	if method != http.MethodGet {
+		return 0, false
+	}
+
+	if opt.HedgePercentile > 0 {
+		if delay, ok := c.latency.Percentile(opt.HedgePercentile); ok {
+			return delay, true
+		}
+	}
+
+	if opt.HedgeDelay > 0 {
+		return opt.HedgeDelay, true
+	}
+	return 0, false
+}
+
+// hedgeResult represents the outcome of one hedged attempt
+type hedgeResult struct {
+	resp  *Response
+	err   error
+	hedge bool
+}
+
+// doHedged sends the request and, if it has not completed after delay, a
+// second identical request. The first successful response wins and the
+// other attempt is cancelled.
+func (c *defaultClient) doHedged(ctx context.Context, method, url string, body RequestBody, opt *RequestOption, tried *endpointSet, delay time.Duration) (*Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	launch := func(hedge bool) {
+		go func() {
+			resp, err := c.send(ctx, method, url, body, opt, tried)
+			results <- hedgeResult{resp: resp, err: err, hedge: hedge}
+		}()
+	}
+
+	launch(false)
+	inFlight := 1
+	hedged := false
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				inFlight++
+				c.hedged.Increment()
+				launch(true)
+			}
+		case result := <-results:
+			inFlight--
+			if result.err == nil {
+				if result.hedge {
+					c.hedgeWins.Increment()
+				}
+				return result.resp, nil
+			}
+
+			// Wait for the other attempt before reporting a failure
+			lastErr = result.err
+			if inFlight == 0 {
+				return nil, lastErr
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSlowFirstServer returns a server whose first request blocks until the
+// client goes away, while later requests answer immediately
+func newSlowFirstServer(requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("stock"))
+	}))
+}
+
+func newHedgeTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestHedgedGet(t *testing.T) {
+	var requests int32
+	server := newSlowFirstServer(&requests)
+	defer server.Close()
+
+	client := httpclient.NewClient(newHedgeTestConfig(), server.URL)
+
+	start := time.Now()
+	resp, err := client.Get(context.Background(), "/inventory/42", &httpclient.RequestOption{
+		MaxBodySize: 1024,
+		HedgeDelay:  20 * time.Millisecond,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte("stock"), resp.Body)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, httpclient.Stats{HedgedRequests: 1, HedgeWins: 1}, client.Stats())
+}
+
+func TestHedgeNotSentForFastResponse(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newHedgeTestConfig(), server.URL)
+
+	_, err := client.Get(context.Background(), "/inventory/42", &httpclient.RequestOption{
+		MaxBodySize: 1024,
+		HedgeDelay:  time.Second,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, httpclient.Stats{}, client.Stats())
+}
+
+func TestHedgeOnlyForGet(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newHedgeTestConfig(), server.URL)
+
+	_, err := client.Post(context.Background(), "/orders", []byte("{}"), &httpclient.RequestOption{
+		MaxBodySize: 1024,
+		HedgeDelay:  time.Millisecond,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, int64(0), client.Stats().HedgedRequests)
+}
+
+func TestHedgePercentile(t *testing.T) {
+	var slowMode, blocked int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&slowMode) == 1 && atomic.CompareAndSwapInt32(&blocked, 0, 1) {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(newHedgeTestConfig(), server.URL)
+	opt := &httpclient.RequestOption{
+		MaxBodySize:     1024,
+		HedgePercentile: 0.9,
+	}
+
+	// Warm up the latency samples
+	for i := 0; i < 25; i++ {
+		_, err := client.Get(context.Background(), "/inventory/42", opt)
+		require.NoError(t, err)
+	}
+	before := client.Stats()
+
+	atomic.StoreInt32(&slowMode, 1)
+	start := time.Now()
+	_, err := client.Get(context.Background(), "/inventory/42", opt)
+
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, before.HedgeWins+1, client.Stats().HedgeWins)
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// attemptKey is the context key holding the attempt number
+type attemptKey struct{}
+
+// WithInterceptors adds interceptors around every request attempt. The first
+// interceptor is the outermost one.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *defaultClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// intercept runs the request through the interceptor chain
+func (c *defaultClient) intercept(req *http.Request, info *AttemptInfo, invoke Invoker) (*Response, error) {
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.interceptors[i], invoke
+		invoke = func(req *http.Request) (*Response, error) {
+			return interceptor(req, info, next)
+		}
+	}
+	return invoke(req)
+}
+
+// attemptFromContext returns the 1-based attempt number stored in ctx
+func attemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
@@ -0,0 +1,368 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"order-system/pkg/platform/logger"
+)
+
+// redacted replaces sensitive values in logs
+const redacted = "[REDACTED]"
+
+// LoggingConfig represents the settings of the logging interceptor
+type LoggingConfig struct {
+	SuccessLevel logger.Level
+	FailureLevel logger.Level
+
+	// LogBodies adds request and response bodies, truncated to MaxBodyBytes
+	LogBodies    bool
+	MaxBodyBytes int
+
+	// RedactHeaders lists headers whose values are never logged
+	RedactHeaders []string
+	// RedactFields lists JSON fields, at any depth, and form and query
+	// parameters whose values are never logged
+	RedactFields []string
+}
+
+// DefaultLoggingConfig returns a logging configuration that logs successful
+// attempts at info level and failures at error level without bodies
+func DefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		SuccessLevel:  logger.Info,
+		FailureLevel:  logger.Error,
+		MaxBodyBytes:  1024,
+		RedactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+		RedactFields:  []string{"password", "card_number", "cardNumber", "cvv", "access_token", "client_secret"},
+	}
+}
+
+// NewLoggingInterceptor creates an interceptor that logs every request
+// attempt with sensitive headers, JSON fields and parameters redacted. Attempts that fail
+// or receive a status of 400 or above are logged at FailureLevel.
+func NewLoggingInterceptor(log logger.Logger, cfg LoggingConfig) Interceptor {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1024
+	}
+
+	redactHeaders := make(map[string]bool, len(cfg.RedactHeaders))
+	for _, h := range cfg.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	redactFields := make(map[string]bool, len(cfg.RedactFields))
+	for _, f := range cfg.RedactFields {
+		redactFields[strings.ToLower(f)] = true
+	}
+
+	log = log.WithComponent("http_client")
+
+	return func(req *http.Request, info *AttemptInfo, next Invoker) (*Response, error) {
+		fields := []logger.Field{
+			{Key: "client", Value: info.Client},
+			{Key: "method", Value: req.Method},
+			{Key: "url", Value: redactURL(req.URL, redactFields)},
+			{Key: "attempt", Value: info.Attempt},
+			{Key: "request_headers", Value: redactHeaderValues(req.Header, redactHeaders)},
+		}
+		// The body is read before compression so that it can be redacted
+		if cfg.LogBodies && info.Body != nil {
+			contentType := info.Body.ContentType()
+			if contentType == "" {
+				contentType = req.Header.Get("Content-Type")
+			}
+			if body, err := requestBodyValue(info.Body, contentType, cfg.MaxBodyBytes, redactFields); err == nil {
+				fields = append(fields, logger.Field{Key: "request_body", Value: body})
+			}
+		}
+
+		start := time.Now()
+		resp, err := next(req)
+		fields = append(fields, logger.Field{Key: "duration_ms", Value: float64(time.Since(start)) / float64(time.Millisecond)})
+
+		if resp != nil {
+			fields = append(fields,
+				logger.Field{Key: "status", Value: resp.StatusCode},
+				logger.Field{Key: "response_headers", Value: redactHeaderValues(resp.Headers, redactHeaders)},
+			)
+			if cfg.LogBodies {
+				fields = append(fields, logger.Field{
+					Key:   "response_body",
+					Value: truncate(redactBody(resp.Body, http.Header(resp.Headers).Get("Content-Type"), redactFields), cfg.MaxBodyBytes),
+				})
+			}
+		}
+
+		ctx := req.Context()
+		if err != nil || resp.StatusCode >= 400 {
+			logAt(ctx, log, cfg.FailureLevel, "http request failed", err, fields)
+		} else {
+			logAt(ctx, log, cfg.SuccessLevel, "http request completed", nil, fields)
+		}
+		return resp, err
+	}
+}
+
+// logAt writes a log entry at the given level
+func logAt(ctx context.Context, log logger.Logger, level logger.Level, msg string, err error, fields []logger.Field) {
+	if err != nil && level != logger.Error {
+		fields = append(fields, logger.Field{Key: "error", Value: err.Error()})
+	}
+
+	switch level {
+	case logger.Debug:
+		log.Debug(ctx, msg, fields...)
+	case logger.Info:
+		log.Info(ctx, msg, fields...)
+	case logger.Warn:
+		log.Warn(ctx, msg, fields...)
+	default:
+		log.Error(ctx, msg, err, fields...)
+	}
+}
+
+// requestBodyValue returns the request body as it is logged. Only JSON and
+// form bodies are read, and no more than maxBytes of them; other bodies such
+// as multipart uploads are replaced by a placeholder. Bodies without a content
+// type, as sent by Post and Put, are treated as JSON.
+func requestBodyValue(body RequestBody, contentType string, maxBytes int, fields map[string]bool) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	form := mediaType == "application/x-www-form-urlencoded"
+	if !form && mediaType != "" && !isJSONMediaType(mediaType) {
+		return "[" + omittedBodyKind(mediaType) + " body omitted]", nil
+	}
+
+	rc, err := body.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, int64(maxBytes)+1))
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	truncated := len(data) > maxBytes
+	switch {
+	case !truncated:
+		value = redactBody(data, contentType, fields)
+	case form:
+		value = redactQuery(string(data[:maxBytes]), fields)
+	default:
+		// A truncated document does not parse, so it is redacted token by token
+		value = redactJSONPrefix(data[:maxBytes], fields)
+	}
+
+	if len(value) > maxBytes {
+		value, truncated = value[:maxBytes], true
+	}
+	if truncated {
+		value += "...(truncated)"
+	}
+	return value, nil
+}
+
+// isJSONMediaType reports whether mediaType is JSON or a JSON based type
+// such as application/problem+json
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// omittedBodyKind names a body that is not logged, such as "multipart"
+func omittedBodyKind(mediaType string) string {
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return "multipart"
+	}
+	return mediaType
+}
+
+// redactHeaderValues returns a copy of headers with sensitive values replaced
+func redactHeaderValues(headers map[string][]string, redact map[string]bool) map[string]string {
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if redact[http.CanonicalHeaderKey(k)] {
+			result[k] = redacted
+			continue
+		}
+		result[k] = strings.Join(v, ", ")
+	}
+	return result
+}
+
+// redactURL returns the URL with the values of sensitive query parameters replaced
+func redactURL(u *url.URL, fields map[string]bool) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	redactedURL := *u
+	redactedURL.RawQuery = redactQuery(u.RawQuery, fields)
+	return redactedURL.String()
+}
+
+// redactBody replaces the values of sensitive fields in a form-encoded or
+// JSON body
+func redactBody(body []byte, contentType string, fields map[string]bool) string {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		return redactQuery(string(body), fields)
+	}
+	return redactJSON(body, fields)
+}
+
+// redactQuery replaces the values of sensitive parameters in a URL-encoded
+// query, keeping the order of the parameters
+func redactQuery(query string, fields map[string]bool) string {
+	if len(fields) == 0 {
+		return query
+	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && fields[strings.ToLower(name)] {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// redactJSON replaces the values of sensitive fields in a JSON document.
+// Bodies that are not JSON are returned unchanged.
+func redactJSON(body []byte, fields map[string]bool) string {
+	if len(fields) == 0 || len(body) == 0 {
+		return string(body)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return string(body)
+	}
+
+	data, err := json.Marshal(redactValue(doc, fields))
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// redactJSONPrefix redacts sensitive fields in the beginning of a JSON
+// document, such as a truncated body. Tokens are copied up to the first one
+// that is incomplete, so a value cut off by the truncation is never logged.
+func redactJSONPrefix(body []byte, fields map[string]bool) string {
+	type container struct {
+		object bool
+		items  int // keys and values written so far
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var (
+		b          strings.Builder
+		stack      []container
+		redactNext bool
+		skipDepth  int
+	)
+	// separate writes the separator before the next key or value
+	separate := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		switch {
+		case top.object && top.items%2 == 1:
+			b.WriteByte(':')
+		case top.items > 0:
+			b.WriteByte(',')
+		}
+	}
+	// written counts a complete key or value in the enclosing container
+	written := func() {
+		if len(stack) > 0 {
+			stack[len(stack)-1].items++
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return b.String()
+		}
+
+		delim, isDelim := tok.(json.Delim)
+		if skipDepth > 0 {
+			// Inside a redacted object or array
+			if isDelim && (delim == '{' || delim == '[') {
+				skipDepth++
+			} else if isDelim {
+				if skipDepth--; skipDepth == 0 {
+					written()
+				}
+			}
+			continue
+		}
+
+		switch {
+		case isDelim && (delim == '{' || delim == '['):
+			separate()
+			if redactNext {
+				redactNext = false
+				b.WriteString(`"` + redacted + `"`)
+				skipDepth = 1
+				continue
+			}
+			b.WriteRune(rune(delim))
+			stack = append(stack, container{object: delim == '{'})
+		case isDelim:
+			b.WriteRune(rune(delim))
+			stack = stack[:len(stack)-1]
+			written()
+		default:
+			separate()
+			isKey := len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].items%2 == 0
+			if redactNext {
+				redactNext = false
+				tok = redacted
+			} else if key, ok := tok.(string); ok && isKey {
+				redactNext = fields[strings.ToLower(key)]
+			}
+			data, _ := json.Marshal(tok)
+			b.Write(data)
+			written()
+		}
+	}
+}
+
+// redactValue walks a decoded JSON value and redacts sensitive fields
+func redactValue(v interface{}, fields map[string]bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			if fields[strings.ToLower(k)] {
+				value[k] = redacted
+				continue
+			}
+			value[k] = redactValue(child, fields)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = redactValue(child, fields)
+		}
+	}
+	return v
+}
+
+// truncate shortens s to at most max bytes
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "...(truncated)"
+}
@@ -0,0 +1,298 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+	"order-system/pkg/platform/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger implements logger.Logger by recording entries
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logger.Entry
+}
+
+func (l *recordingLogger) record(level logger.Level, msg string, err error, fields []logger.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logger.Entry{Level: level, Message: msg, Error: err, Fields: fields})
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Debug, msg, nil, fields)
+}
+
+func (l *recordingLogger) Info(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Info, msg, nil, fields)
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Warn, msg, nil, fields)
+}
+
+func (l *recordingLogger) Error(ctx context.Context, msg string, err error, fields ...logger.Field) {
+	l.record(logger.Error, msg, err, fields)
+}
+
+func (l *recordingLogger) WithComponent(component string) logger.Logger { return l }
+
+func (l *recordingLogger) WithFields(fields ...logger.Field) logger.Logger { return l }
+
+func (l *recordingLogger) Entries() []logger.Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logger.Entry(nil), l.entries...)
+}
+
+func fieldValue(entry logger.Entry, key string) interface{} {
+	for _, f := range entry.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+func newLoggingTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestLoggingInterceptorSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":42,"payment":{"card_number":"4111111111111111"}}`))
+	}))
+	defer server.Close()
+
+	log := &recordingLogger{}
+	cfg := httpclient.DefaultLoggingConfig()
+	cfg.LogBodies = true
+
+	client := httpclient.NewClient(newLoggingTestConfig(), server.URL,
+		httpclient.WithName("payments"),
+		httpclient.WithAuth(httpclient.NewBearerAuth("secret-token")),
+		httpclient.WithInterceptors(httpclient.NewLoggingInterceptor(log, cfg)))
+
+	_, err := client.Post(context.Background(), "/charges", []byte(`{"amount":10,"cvv":"123"}`), nil)
+	require.NoError(t, err)
+
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, logger.Info, entry.Level)
+	assert.Equal(t, "payments", fieldValue(entry, "client"))
+	assert.Equal(t, http.MethodPost, fieldValue(entry, "method"))
+	assert.Equal(t, server.URL+"/charges", fieldValue(entry, "url"))
+	assert.Equal(t, http.StatusOK, fieldValue(entry, "status"))
+	assert.Equal(t, 1, fieldValue(entry, "attempt"))
+	assert.NotNil(t, fieldValue(entry, "duration_ms"))
+
+	headers := fieldValue(entry, "request_headers").(map[string]string)
+	assert.Equal(t, "[REDACTED]", headers["Authorization"])
+
+	assert.Equal(t, `{"amount":10,"cvv":"[REDACTED]"}`, fieldValue(entry, "request_body"))
+	assert.Equal(t, `{"id":42,"payment":{"card_number":"[REDACTED]"}}`, fieldValue(entry, "response_body"))
+}
+
+func TestLoggingInterceptorFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream unavailable, please retry later"))
+	}))
+	defer server.Close()
+
+	log := &recordingLogger{}
+	cfg := httpclient.DefaultLoggingConfig()
+	cfg.FailureLevel = logger.Warn
+	cfg.LogBodies = true
+	cfg.MaxBodyBytes = 8
+
+	client := httpclient.NewClient(newLoggingTestConfig(), server.URL,
+		httpclient.WithInterceptors(httpclient.NewLoggingInterceptor(log, cfg)))
+
+	_, err := client.Get(context.Background(), "/orders", nil)
+	require.NoError(t, err)
+
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logger.Warn, entries[0].Level)
+	assert.Equal(t, http.StatusBadGateway, fieldValue(entries[0], "status"))
+	assert.Equal(t, "upstream...(truncated)", fieldValue(entries[0], "response_body"))
+}
+
+func TestLoggingInterceptorRedactsCompressedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	log := &recordingLogger{}
+	cfg := httpclient.DefaultLoggingConfig()
+	cfg.LogBodies = true
+
+	client := httpclient.NewClient(newLoggingTestConfig(), server.URL,
+		httpclient.WithInterceptors(httpclient.NewLoggingInterceptor(log, cfg)))
+
+	body := httpclient.NewBytesBody([]byte(`{"amount":10,"card_number":"4111111111111111"}`), "application/json")
+	_, err := client.Do(context.Background(), http.MethodPost, "/charges", body, &httpclient.RequestOption{
+		CompressThreshold: 1,
+		MaxBodySize:       1024,
+	})
+	require.NoError(t, err)
+
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, `{"amount":10,"card_number":"[REDACTED]"}`, fieldValue(entries[0], "request_body"))
+}
+
+func TestLoggingInterceptorRedactsParameters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	log := &recordingLogger{}
+	cfg := httpclient.DefaultLoggingConfig()
+	cfg.LogBodies = true
+
+	client := httpclient.NewClient(newLoggingTestConfig(), server.URL,
+		httpclient.WithInterceptors(httpclient.NewLoggingInterceptor(log, cfg)))
+
+	body := httpclient.NewFormBody(url.Values{"client_id": {"orders"}, "client_secret": {"s3cret"}})
+	_, err := client.Do(context.Background(), http.MethodPost, "/token?access_token=abc&scope=read", body, nil)
+	require.NoError(t, err)
+
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, server.URL+"/token?access_token=[REDACTED]&scope=read", fieldValue(entries[0], "url"))
+	assert.Equal(t, "client_id=orders&client_secret=[REDACTED]", fieldValue(entries[0], "request_body"))
+}
+
+// countingBody is a request body that records how many bytes were read
+type countingBody struct {
+	data        []byte
+	contentType string
+	read        int64
+}
+
+func (b *countingBody) ContentType() string { return b.contentType }
+
+func (b *countingBody) Open() (io.ReadCloser, error) {
+	return io.NopCloser(&countingReader{r: bytes.NewReader(b.data), n: &b.read}), nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+func TestLoggingInterceptorBoundsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	log := &recordingLogger{}
+	cfg := httpclient.DefaultLoggingConfig()
+	cfg.LogBodies = true
+	cfg.MaxBodyBytes = 64
+
+	client := httpclient.NewClient(newLoggingTestConfig(), server.URL,
+		httpclient.WithInterceptors(httpclient.NewLoggingInterceptor(log, cfg)))
+
+	t.Run("json", func(t *testing.T) {
+		doc := `{"cvv":"123","items":[{"sku":"A-1","qty":2}],"card_number":"4111111111111111","note":"` +
+			strings.Repeat("x", 64<<10) + `"}`
+		body := &countingBody{data: []byte(doc), contentType: "application/json"}
+		_, err := client.Do(context.Background(), http.MethodPost, "/charges", body, &httpclient.RequestOption{
+			MaxBodySize: 1024,
+		})
+		require.NoError(t, err)
+
+		entries := log.Entries()
+		logged := fieldValue(entries[len(entries)-1], "request_body")
+		assert.Equal(t, `{"cvv":"[REDACTED]","items":[{"sku":"A-1","qty":2}],"card_number...(truncated)`, logged)
+		// The transport reads the body once; logging reads no more than the limit
+		assert.LessOrEqual(t, atomic.LoadInt64(&body.read), int64(len(doc)+cfg.MaxBodyBytes+1))
+	})
+
+	t.Run("value cut by the limit", func(t *testing.T) {
+		doc := `{"amount":10,"password":"` + strings.Repeat("p", 100) + `"}`
+		body := httpclient.NewBytesBody([]byte(doc), "application/json")
+		_, err := client.Do(context.Background(), http.MethodPost, "/charges", body, nil)
+		require.NoError(t, err)
+
+		entries := log.Entries()
+		assert.Equal(t, `{"amount":10,"password"...(truncated)`, fieldValue(entries[len(entries)-1], "request_body"))
+
+		doc = `{"amount":10,"note":"` + strings.Repeat("n", 100) + `"}`
+		body = httpclient.NewBytesBody([]byte(doc), "application/json")
+		_, err = client.Do(context.Background(), http.MethodPost, "/charges", body, nil)
+		require.NoError(t, err)
+
+		entries = log.Entries()
+		assert.Equal(t, `{"amount":10,"note"...(truncated)`, fieldValue(entries[len(entries)-1], "request_body"))
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		body := &countingBody{
+			data:        bytes.Repeat([]byte("z"), 64<<10),
+			contentType: "multipart/form-data; boundary=xyz",
+		}
+		_, err := client.Do(context.Background(), http.MethodPost, "/labels", body, nil)
+		require.NoError(t, err)
+
+		entries := log.Entries()
+		assert.Equal(t, "[multipart body omitted]", fieldValue(entries[len(entries)-1], "request_body"))
+		assert.Equal(t, int64(len(body.data)), atomic.LoadInt64(&body.read))
+	})
+}
+
+func TestLoggingInterceptorAttempts(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	log := &recordingLogger{}
+	client := httpclient.NewClient(newLoggingTestConfig(), down.URL,
+		httpclient.WithInterceptors(httpclient.NewLoggingInterceptor(log, httpclient.DefaultLoggingConfig())))
+
+	_, err := client.Get(context.Background(), "/orders", &httpclient.RequestOption{
+		RetryCount:    2,
+		RetryInterval: time.Millisecond,
+		MaxBodySize:   1024,
+	})
+	require.Error(t, err)
+
+	entries := log.Entries()
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, logger.Error, entry.Level)
+		assert.Equal(t, i+1, fieldValue(entry, "attempt"))
+		assert.Error(t, entry.Error)
+	}
+}
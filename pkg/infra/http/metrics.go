@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"order-system/pkg/platform/metrics"
+)
+
+// Metric names recorded for outbound requests
+const (
+	MetricRequests     = "http_client_requests_total"
+	MetricErrors       = "http_client_errors_total"
+	MetricRetries      = "http_client_retries_total"
+	MetricDuration     = "http_client_request_duration_seconds"
+	MetricResponseSize = "http_client_response_size_bytes"
+)
+
+// unknownRoute labels requests sent without a route template
+const unknownRoute = "unknown"
+
+// responseSizeBuckets are the bucket upper bounds of response sizes in bytes
+var responseSizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// WithMetrics records request, error and retry counts, latency and response
+// sizes for every attempt. Metrics are labelled with the client name, method,
+// route template and status class; raw URLs are never used as labels. If a
+// metric name is already registered with another type, every request fails.
+func WithMetrics(collector metrics.Collector) ClientOption {
+	return func(c *defaultClient) {
+		if err := registerClientMetrics(collector); err != nil {
+			c.initErr = &Error{
+				Message: "invalid metrics configuration",
+				Cause:   err,
+			}
+		}
+		c.interceptors = append(c.interceptors, metricsInterceptor(collector))
+	}
+}
+
+// registerClientMetrics registers the metrics shared by all clients
+func registerClientMetrics(collector metrics.Collector) error {
+	counters := []struct{ name, description string }{
+		{MetricRequests, "Total number of outbound HTTP request attempts"},
+		{MetricErrors, "Total number of failed outbound HTTP request attempts"},
+		{MetricRetries, "Total number of outbound HTTP request retries"},
+	}
+	for _, m := range counters {
+		if err := metrics.EnsureRegistered(collector, m.name, metrics.Counter, m.description); err != nil {
+			return err
+		}
+	}
+	if err := metrics.EnsureHistogram(collector, MetricDuration, "Outbound HTTP request latency in seconds", metrics.DefaultBuckets); err != nil {
+		return err
+	}
+	return metrics.EnsureHistogram(collector, MetricResponseSize, "Outbound HTTP response body size in bytes", responseSizeBuckets)
+}
+
+// metricsInterceptor records the metrics of every attempt
+func metricsInterceptor(collector metrics.Collector) Interceptor {
+	return func(req *http.Request, info *AttemptInfo, next Invoker) (*Response, error) {
+		start := time.Now()
+		resp, err := next(req)
+		duration := time.Since(start)
+
+		route := info.Route
+		if route == "" {
+			route = unknownRoute
+		}
+
+		status := "error"
+		if err == nil {
+			status = StatusClass(resp.StatusCode)
+		}
+
+		labels := metrics.Labels{
+			"client":       info.Client,
+			"method":       req.Method,
+			"route":        route,
+			"status_class": status,
+		}
+		collector.IncrementCounter(MetricRequests, 1, labels)
+		collector.ObserveHistogram(MetricDuration, duration.Seconds(), labels)
+		if err != nil || resp.StatusCode >= 500 {
+			collector.IncrementCounter(MetricErrors, 1, labels)
+		}
+		if resp != nil {
+			collector.ObserveHistogram(MetricResponseSize, float64(len(resp.Body)), labels)
+		}
+		if info.Attempt > 1 {
+			collector.IncrementCounter(MetricRetries, 1, metrics.Labels{
+				"client": info.Client,
+				"method": req.Method,
+				"route":  route,
+			})
+		}
+
+		return resp, err
+	}
+}
+
+// StatusClass returns the class of an HTTP status code, e.g. "2xx"
+func StatusClass(code int) string {
+	switch {
+	case code >= 500:
+		return "5xx"
+	case code >= 400:
+		return "4xx"
+	case code >= 300:
+		return "3xx"
+	case code >= 200:
+		return "2xx"
+	default:
+		return "1xx"
+	}
+}
@@ -0,0 +1,119 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+	"order-system/pkg/platform/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetricsTestCollector(t *testing.T) metrics.Collector {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, err := metrics.New(cfg)
+	require.NoError(t, err)
+	return collector
+}
+
+func TestMetricsRecorded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orders/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("order"))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	collector := newMetricsTestCollector(t)
+	client := httpclient.NewClient(cfg, server.URL,
+		httpclient.WithName("orders"),
+		httpclient.WithMetrics(collector))
+
+	opt := &httpclient.RequestOption{MaxBodySize: 1024, Route: "/orders/{id}"}
+	for _, path := range []string{"/orders/1", "/orders/2", "/orders/missing"} {
+		_, err := client.Get(context.Background(), path, opt)
+		require.NoError(t, err)
+	}
+
+	ok := metrics.Labels{"client": "orders", "method": "GET", "route": "/orders/{id}", "status_class": "2xx"}
+	notFound := metrics.Labels{"client": "orders", "method": "GET", "route": "/orders/{id}", "status_class": "4xx"}
+
+	assert.Equal(t, 2.0, collector.GetCounter(httpclient.MetricRequests, ok))
+	assert.Equal(t, 1.0, collector.GetCounter(httpclient.MetricRequests, notFound))
+	assert.Equal(t, 0.0, collector.GetCounter(httpclient.MetricErrors, ok))
+	assert.Len(t, collector.GetHistogram(httpclient.MetricDuration, ok), 2)
+	assert.Equal(t, []float64{5, 5}, collector.GetHistogram(httpclient.MetricResponseSize, ok))
+
+	// Response sizes are counted in byte buckets
+	for _, h := range collector.CollectHistograms() {
+		if h.Name == httpclient.MetricResponseSize && h.Labels["status_class"] == "2xx" {
+			assert.Equal(t, 256.0, h.Buckets[0])
+			assert.Equal(t, uint64(2), h.Counts[0])
+		}
+	}
+}
+
+func TestMetricsTypeConflict(t *testing.T) {
+	collector := newMetricsTestCollector(t)
+	require.NoError(t, collector.Register(httpclient.MetricRequests, metrics.Gauge, "Requests in flight"))
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	client := httpclient.NewClient(cfg, "http://127.0.0.1:1", httpclient.WithMetrics(collector))
+
+	_, err := client.Get(context.Background(), "/orders", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid metrics configuration")
+}
+
+func TestMetricsErrorsAndRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	collector := newMetricsTestCollector(t)
+	client := httpclient.NewClient(cfg, server.URL, httpclient.WithMetrics(collector))
+
+	_, err := client.Get(context.Background(), "/inventory/42", &httpclient.RequestOption{
+		RetryCount:    1,
+		RetryInterval: time.Millisecond,
+		MaxBodySize:   1024,
+	})
+	require.NoError(t, err)
+
+	failed := metrics.Labels{"client": "", "method": "GET", "route": "unknown", "status_class": "error"}
+	unavailable := metrics.Labels{"client": "", "method": "GET", "route": "unknown", "status_class": "5xx"}
+
+	assert.Equal(t, 1.0, collector.GetCounter(httpclient.MetricErrors, failed))
+	assert.Equal(t, 1.0, collector.GetCounter(httpclient.MetricErrors, unavailable))
+	assert.Equal(t, 1.0, collector.GetCounter(httpclient.MetricRetries, metrics.Labels{"client": "", "method": "GET", "route": "unknown"}))
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Equal(t, "1xx", httpclient.StatusClass(101))
+	assert.Equal(t, "2xx", httpclient.StatusClass(204))
+	assert.Equal(t, "3xx", httpclient.StatusClass(304))
+	assert.Equal(t, "4xx", httpclient.StatusClass(429))
+	assert.Equal(t, "5xx", httpclient.StatusClass(503))
+}
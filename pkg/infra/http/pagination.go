@@ -0,0 +1,353 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PageStrategy determines how a paginated endpoint is traversed
+type PageStrategy interface {
+	// First returns the URL of the first page
+	First(rawURL string) (string, error)
+	// Next returns the URL of the page following resp, which contained
+	// items entries, or "" if it was the last page
+	Next(pageURL string, resp *Response, items int) (string, error)
+}
+
+// PaginateOptions represents the settings of a pagination iterator
+type PaginateOptions struct {
+	// ItemsField is the dot-separated path of the item array in the JSON
+	// response; the whole response must be an array if empty
+	ItemsField string
+	// MaxPages stops iteration after this many pages; 0 means no limit
+	MaxPages int
+	// Request is passed to every page request
+	Request *RequestOption
+}
+
+// Iterator lazily fetches pages and yields decoded items
+type Iterator[T any] struct {
+	ctx      context.Context
+	client   Client
+	strategy PageStrategy
+	opt      PaginateOptions
+
+	nextURL string
+	pages   int
+	items   []T
+	current T
+	err     error
+	done    bool
+}
+
+// Paginate creates an iterator over the items of a paginated endpoint.
+// Pages are only requested as the iterator advances.
+func Paginate[T any](ctx context.Context, client Client, rawURL string, strategy PageStrategy, opt *PaginateOptions) *Iterator[T] {
+	it := &Iterator[T]{
+		ctx:      ctx,
+		client:   client,
+		strategy: strategy,
+	}
+	if opt != nil {
+		it.opt = *opt
+	}
+
+	it.nextURL, it.err = strategy.First(rawURL)
+	if it.err != nil {
+		it.done = true
+	}
+	return it
+}
+
+// Next advances to the next item, fetching a new page when needed. It
+// returns false when iteration is complete or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.done {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			it.done = true
+			return false
+		}
+	}
+
+	it.current = it.items[0]
+	it.items = it.items[1:]
+	return true
+}
+
+// Item returns the current item
+func (it *Iterator[T]) Item() T {
+	return it.current
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Pages returns the number of pages fetched so far
+func (it *Iterator[T]) Pages() int {
+	return it.pages
+}
+
+// fetch requests the next page
+func (it *Iterator[T]) fetch() error {
+	if err := it.ctx.Err(); err != nil {
+		return err
+	}
+
+	pageURL := it.nextURL
+	resp, err := it.client.Get(it.ctx, pageURL, it.opt.Request)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("failed to fetch page %s: status %d", pageURL, resp.StatusCode),
+		}
+	}
+
+	items, err := decodeItems[T](resp.Body, it.opt.ItemsField)
+	if err != nil {
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Message:    "failed to decode page",
+			Cause:      err,
+		}
+	}
+	it.items = items
+	it.pages++
+
+	it.nextURL, err = it.strategy.Next(pageURL, resp, len(items))
+	if err != nil {
+		return err
+	}
+	if it.nextURL == "" || (it.opt.MaxPages > 0 && it.pages >= it.opt.MaxPages) {
+		it.done = true
+	}
+	return nil
+}
+
+// decodeItems decodes the item array found at field in a JSON body
+func decodeItems[T any](body []byte, field string) ([]T, error) {
+	raw := json.RawMessage(body)
+	if field != "" {
+		value, err := lookupJSON(body, field)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, nil
+		}
+		raw = value
+	}
+
+	var items []T
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// lookupJSON returns the raw value at a dot-separated path, or nil if absent
+func lookupJSON(body []byte, path string) (json.RawMessage, error) {
+	raw := json.RawMessage(body)
+	for _, key := range strings.Split(path, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("%s is not an object: %w", path, err)
+		}
+		value, ok := obj[key]
+		if !ok || string(value) == "null" {
+			return nil, nil
+		}
+		raw = value
+	}
+	return raw, nil
+}
+
+// linkStrategy follows rel="next" entries of the Link header
+type linkStrategy struct {
+	baseURL string
+}
+
+// NewLinkStrategy creates a strategy that follows RFC 8288 Link headers.
+// baseURL is the base URL of the client; next links are resolved against
+// the page URL and made relative to baseURL again, so that a path prefix
+// of baseURL is not repeated. Links outside baseURL fail iteration rather
+// than being sent to another host. Clients with a load balancer pass the
+// path prefix shared by their endpoints, accepting links to any host.
+func NewLinkStrategy(baseURL string) PageStrategy {
+	return linkStrategy{baseURL: baseURL}
+}
+
+// First implements PageStrategy.First
+func (linkStrategy) First(rawURL string) (string, error) {
+	return rawURL, nil
+}
+
+// Next implements PageStrategy.Next
+func (s linkStrategy) Next(pageURL string, resp *Response, items int) (string, error) {
+	for _, header := range http.Header(resp.Headers).Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !hasRelNext(params) {
+				continue
+			}
+			target = strings.Trim(strings.TrimSpace(target), "<>")
+
+			u, err := url.Parse(target)
+			if err != nil {
+				return "", fmt.Errorf("invalid next link %q: %w", target, err)
+			}
+			return s.relative(pageURL, u)
+		}
+	}
+	return "", nil
+}
+
+// relative resolves a next link against the page URL and returns it
+// relative to the base URL
+func (s linkStrategy) relative(pageURL string, link *url.URL) (string, error) {
+	base, err := url.Parse(s.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", s.baseURL, err)
+	}
+	page, err := url.Parse(s.baseURL + pageURL)
+	if err != nil {
+		return "", err
+	}
+	next := page.ResolveReference(link)
+
+	if base.Host != "" && (next.Scheme != base.Scheme || next.Host != base.Host) {
+		return "", fmt.Errorf("next link %q points outside %s", link, s.baseURL)
+	}
+	prefix := strings.TrimSuffix(base.EscapedPath(), "/")
+	uri := next.RequestURI()
+	if !strings.HasPrefix(uri, prefix) {
+		return "", fmt.Errorf("next link %q points outside %s", link, s.baseURL)
+	}
+	rest := uri[len(prefix):]
+	if rest != "" && rest[0] != '/' && rest[0] != '?' {
+		return "", fmt.Errorf("next link %q points outside %s", link, s.baseURL)
+	}
+	return rest, nil
+}
+
+// hasRelNext reports whether Link parameters contain rel="next"
+func hasRelNext(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(name, "rel") {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+			if strings.EqualFold(rel, "next") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CursorStrategy pages with an opaque cursor returned in the response body
+type CursorStrategy struct {
+	// Param is the query parameter carrying the cursor
+	Param string
+	// Field is the dot-separated path of the next cursor in the JSON response
+	Field string
+}
+
+// First implements PageStrategy.First
+func (s CursorStrategy) First(rawURL string) (string, error) {
+	return rawURL, nil
+}
+
+// Next implements PageStrategy.Next
+func (s CursorStrategy) Next(pageURL string, resp *Response, items int) (string, error) {
+	raw, err := lookupJSON(resp.Body, s.Field)
+	if err != nil || raw == nil {
+		return "", err
+	}
+
+	// Numeric cursors are passed on as written, as IDs above 2^53 do not
+	// survive a float64
+	var cursor interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&cursor); err != nil {
+		return "", fmt.Errorf("invalid cursor: %w", err)
+	}
+	var value string
+	switch c := cursor.(type) {
+	case string:
+		value = c
+	case json.Number:
+		value = c.String()
+	default:
+		return "", fmt.Errorf("invalid cursor type %T", cursor)
+	}
+	if value == "" {
+		return "", nil
+	}
+	return setQueryParams(pageURL, map[string]string{s.Param: value})
+}
+
+// OffsetStrategy pages with offset and limit query parameters, stopping at
+// the first page with fewer than Limit items
+type OffsetStrategy struct {
+	OffsetParam string
+	LimitParam  string
+	Limit       int
+}
+
+// First implements PageStrategy.First
+func (s OffsetStrategy) First(rawURL string) (string, error) {
+	if s.Limit <= 0 {
+		return "", fmt.Errorf("offset pagination requires a positive limit")
+	}
+	return setQueryParams(rawURL, map[string]string{
+		s.OffsetParam: "0",
+		s.LimitParam:  strconv.Itoa(s.Limit),
+	})
+}
+
+// Next implements PageStrategy.Next
+func (s OffsetStrategy) Next(pageURL string, resp *Response, items int) (string, error) {
+	if items < s.Limit {
+		return "", nil
+	}
+
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	offset, _ := strconv.Atoi(u.Query().Get(s.OffsetParam))
+	return setQueryParams(pageURL, map[string]string{
+		s.OffsetParam: strconv.Itoa(offset + items),
+	})
+}
+
+// setQueryParams returns rawURL with the given query parameters replaced
+func setQueryParams(rawURL string, params map[string]string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,229 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pagedOrder struct {
+	ID int `json:"id"`
+}
+
+func newPaginationTestClient(server *httptest.Server) httpclient.Client {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 4096
+	return httpclient.NewClient(cfg, server.URL)
+}
+
+// orderRange returns the orders with ids in [from, to)
+func orderRange(from, to int) []pagedOrder {
+	var orders []pagedOrder
+	for i := from; i < to; i++ {
+		orders = append(orders, pagedOrder{ID: i})
+	}
+	return orders
+}
+
+func collectIDs(t *testing.T, it *httpclient.Iterator[pagedOrder]) []int {
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	require.NoError(t, it.Err())
+	return ids
+}
+
+func TestPaginateLinkHeader(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orders?page=%d>; rel="next", <%s/orders?page=2>; rel="last"`, server.URL, page+1, server.URL))
+		}
+		json.NewEncoder(w).Encode(orderRange(page*2, page*2+2))
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders?page=0",
+		httpclient.NewLinkStrategy(server.URL), nil)
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, collectIDs(t, it))
+	assert.Equal(t, 3, it.Pages())
+}
+
+func TestPaginateLinkHeaderBasePath(t *testing.T) {
+	var server *httptest.Server
+	var paths []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v1/orders?page=2>; rel="next"`, server.URL))
+		case "2":
+			w.Header().Set("Link", `</v1/orders?page=3>; rel="next"`)
+		}
+		json.NewEncoder(w).Encode(orderRange(len(paths), len(paths)+1))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 4096
+	client := httpclient.NewClient(cfg, server.URL+"/v1")
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), client, "/orders",
+		httpclient.NewLinkStrategy(server.URL+"/v1"), nil)
+
+	assert.Equal(t, []int{1, 2, 3}, collectIDs(t, it))
+	assert.Equal(t, []string{"/v1/orders", "/v1/orders?page=2", "/v1/orders?page=3"}, paths)
+}
+
+func TestPaginateLinkHeaderOtherHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<https://elsewhere.example.com/v1/orders?page=2>; rel="next"`)
+		json.NewEncoder(w).Encode(orderRange(0, 1))
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders",
+		httpclient.NewLinkStrategy(server.URL), nil)
+
+	assert.False(t, it.Next())
+	require.Error(t, it.Err())
+	assert.Contains(t, it.Err().Error(), "outside")
+}
+
+func TestPaginateCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		assert.Equal(t, "pending", r.URL.Query().Get("status"))
+
+		body := map[string]interface{}{}
+		switch cursor {
+		case "":
+			body["data"] = map[string]interface{}{"orders": orderRange(0, 2)}
+			body["meta"] = map[string]interface{}{"next_cursor": "abc"}
+		case "abc":
+			body["data"] = map[string]interface{}{"orders": orderRange(2, 3)}
+			body["meta"] = map[string]interface{}{"next_cursor": nil}
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders?status=pending",
+		httpclient.CursorStrategy{Param: "cursor", Field: "meta.next_cursor"},
+		&httpclient.PaginateOptions{ItemsField: "data.orders"})
+
+	assert.Equal(t, []int{0, 1, 2}, collectIDs(t, it))
+}
+
+func TestPaginateNumericCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			// 2^53 + 1 is not representable as a float64
+			w.Write([]byte(`{"orders":[{"id":0}],"next":9007199254740993}`))
+		case "9007199254740993":
+			w.Write([]byte(`{"orders":[{"id":1}],"next":null}`))
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+			w.Write([]byte(`{"orders":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders",
+		httpclient.CursorStrategy{Param: "cursor", Field: "next"},
+		&httpclient.PaginateOptions{ItemsField: "orders"})
+
+	assert.Equal(t, []int{0, 1}, collectIDs(t, it))
+}
+
+func TestPaginateOffsetLazyWithMaxPages(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		json.NewEncoder(w).Encode(orderRange(offset, offset+limit))
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders",
+		httpclient.OffsetStrategy{OffsetParam: "offset", LimitParam: "limit", Limit: 2},
+		&httpclient.PaginateOptions{MaxPages: 3})
+
+	// Pages are requested only as items are consumed
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	require.True(t, it.Next())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	ids := append([]int{it.Item().ID}, collectIDs(t, it)...)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, ids)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestPaginateOffsetStopsOnShortPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := offset + 2
+		if end > 3 {
+			end = 3
+		}
+		json.NewEncoder(w).Encode(orderRange(offset, end))
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders",
+		httpclient.OffsetStrategy{OffsetParam: "offset", LimitParam: "limit", Limit: 2}, nil)
+
+	assert.Equal(t, []int{0, 1, 2}, collectIDs(t, it))
+	assert.Equal(t, 2, it.Pages())
+}
+
+func TestPaginateContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `</orders?page=next>; rel="next"`)
+		json.NewEncoder(w).Encode(orderRange(0, 1))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := httpclient.Paginate[pagedOrder](ctx, newPaginationTestClient(server), "/orders",
+		httpclient.NewLinkStrategy(server.URL), nil)
+
+	require.True(t, it.Next())
+	cancel()
+
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
+
+func TestPaginateErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	it := httpclient.Paginate[pagedOrder](context.Background(), newPaginationTestClient(server), "/orders",
+		httpclient.NewLinkStrategy(server.URL), nil)
+
+	assert.False(t, it.Next())
+	require.Error(t, it.Err())
+	assert.Contains(t, it.Err().Error(), "status 400")
+}
@@ -0,0 +1,347 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// cassetteBoundary replaces multipart boundaries when matching bodies
+const cassetteBoundary = "recorder-boundary"
+
+// RecorderMode represents whether a recorder captures or serves interactions
+type RecorderMode int
+
+const (
+	// ModeReplay serves recorded interactions without touching the network
+	ModeReplay RecorderMode = iota
+	// ModeRecord sends requests over the network and records the interactions
+	ModeRecord
+)
+
+// RecorderConfig represents the settings of a record/replay transport
+type RecorderConfig struct {
+	Mode RecorderMode
+	// Path is the cassette file interactions are written to and read from
+	Path string
+
+	// RedactHeaders lists headers whose values are not written to the cassette
+	RedactHeaders []string
+	// RedactFields lists JSON body fields whose values are not written to the cassette
+	RedactFields []string
+}
+
+// Cassette represents a set of recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction represents a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest represents a recorded request
+type RecordedRequest struct {
+	Method       string              `json:"method"`
+	Path         string              `json:"path"`
+	Query        url.Values          `json:"query,omitempty"`
+	Headers      map[string][]string `json:"headers,omitempty"`
+	Body         string              `json:"body,omitempty"`
+	BodyEncoding string              `json:"bodyEncoding,omitempty"`
+}
+
+// RecordedResponse represents a recorded response
+type RecordedResponse struct {
+	StatusCode   int                 `json:"statusCode"`
+	Headers      map[string][]string `json:"headers,omitempty"`
+	Body         string              `json:"body,omitempty"`
+	BodyEncoding string              `json:"bodyEncoding,omitempty"`
+}
+
+// Recorder is a cassette-style transport. In record mode it captures real
+// interactions to a file; in replay mode it serves them back, matching on
+// method, path, query and body, and fails requests that match nothing.
+type Recorder struct {
+	cfg           RecorderConfig
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewRecorder creates a recorder. In replay mode the cassette file must exist.
+func NewRecorder(cfg RecorderConfig) (*Recorder, error) {
+	r := &Recorder{
+		cfg:           cfg,
+		redactHeaders: make(map[string]bool),
+		redactFields:  make(map[string]bool),
+		cassette:      &Cassette{},
+	}
+	for _, h := range cfg.RedactHeaders {
+		r.redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, f := range cfg.RedactFields {
+		r.redactFields[strings.ToLower(f)] = true
+	}
+
+	if cfg.Mode == ModeReplay {
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", cfg.Path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Wrap returns a transport that records through next or replays the cassette.
+// It can be passed to WithTransportMiddleware.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if r.cfg.Mode == ModeRecord {
+			return r.record(req, next)
+		}
+		return r.replay(req)
+	})
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// record sends the request and appends the interaction to the cassette
+func (r *Recorder) record(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	reqBody, err := drainRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Store decoded bodies so that cassettes are readable and redactable
+	reader, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   req.URL.Query(),
+			Headers: r.redactedHeaders(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    r.redactedHeaders(resp.Header),
+		},
+	}
+	delete(interaction.Response.Headers, "Content-Length")
+	interaction.Request.Body, interaction.Request.BodyEncoding = encodeRecordedBody(r.redactBody(reqBody))
+	interaction.Response.Body, interaction.Response.BodyEncoding = encodeRecordedBody(r.redactBody(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.saveLocked(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// replay serves the first unused recorded interaction matching the request,
+// falling back to the last used match so that polling requests can repeat
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	body := r.redactBody(reqBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !requestMatches(interaction.Request, req, body) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("recorder: no interaction in %s matches %s %s", r.cfg.Path, req.Method, req.URL.RequestURI())
+	}
+	r.used[match] = true
+
+	recorded := r.cassette.Interactions[match].Response
+	respBody, err := decodeRecordedBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: invalid response body in %s: %w", r.cfg.Path, err)
+	}
+
+	header := http.Header{}
+	for k, v := range recorded.Headers {
+		header[k] = append([]string(nil), v...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// saveLocked writes the cassette to disk; r.mu must be held
+func (r *Recorder) saveLocked() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.cfg.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.cfg.Path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// redactedHeaders returns a copy of headers with sensitive values replaced
+func (r *Recorder) redactedHeaders(headers http.Header) map[string][]string {
+	result := make(map[string][]string, len(headers))
+	for k, v := range headers {
+		if r.redactHeaders[http.CanonicalHeaderKey(k)] {
+			result[k] = []string{redacted}
+			continue
+		}
+		result[k] = append([]string(nil), v...)
+	}
+	return result
+}
+
+// redactBody replaces sensitive JSON fields in a body
+func (r *Recorder) redactBody(body []byte) []byte {
+	if len(r.redactFields) == 0 {
+		return body
+	}
+	return []byte(redactJSON(body, r.redactFields))
+}
+
+// requestMatches reports whether a recorded request matches req
+func requestMatches(recorded RecordedRequest, req *http.Request, body []byte) bool {
+	if recorded.Method != req.Method || recorded.Path != req.URL.Path {
+		return false
+	}
+
+	query := req.URL.Query()
+	if len(recorded.Query) != 0 || len(query) != 0 {
+		if !reflect.DeepEqual(url.Values(recorded.Query), query) {
+			return false
+		}
+	}
+
+	recordedBody, err := decodeRecordedBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return false
+	}
+	recordedBody = normalizeBoundary(recordedBody, http.Header(recorded.Headers).Get("Content-Type"))
+	body = normalizeBoundary(body, req.Header.Get("Content-Type"))
+	return bodiesEqual(recordedBody, body)
+}
+
+// normalizeBoundary replaces the boundary of a multipart body with a fixed
+// one, since MultipartBody generates a new boundary on every build
+func normalizeBoundary(body []byte, contentType string) []byte {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return body
+	}
+	return bytes.ReplaceAll(body, []byte("--"+params["boundary"]), []byte("--"+cassetteBoundary))
+}
+
+// bodiesEqual compares two bodies, ignoring formatting differences in JSON
+func bodiesEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var docA, docB interface{}
+	if json.Unmarshal(a, &docA) != nil || json.Unmarshal(b, &docB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(docA, docB)
+}
+
+// drainRequestBody reads the request body and replaces it with a fresh reader
+func drainRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// encodeRecordedBody stores text bodies as-is and binary bodies as base64
+func encodeRecordedBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeRecordedBody reverses encodeRecordedBody
+func decodeRecordedBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,163 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecorderTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 30 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "partner", "orders.json")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"id":42,"status":"` + r.URL.Query().Get("view") + `"}`))
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		}
+	}))
+
+	recorder, err := httpclient.NewRecorder(httpclient.RecorderConfig{
+		Mode:          httpclient.ModeRecord,
+		Path:          cassette,
+		RedactHeaders: []string{"Authorization"},
+		RedactFields:  []string{"card_number"},
+	})
+	require.NoError(t, err)
+
+	client := httpclient.NewClient(newRecorderTestConfig(), server.URL,
+		httpclient.WithAuth(httpclient.NewBearerAuth("secret-token")),
+		httpclient.WithTransportMiddleware(recorder.Wrap))
+
+	_, err = client.Get(context.Background(), "/orders/42?view=full", nil)
+	require.NoError(t, err)
+	_, err = client.Post(context.Background(), "/orders", []byte(`{"sku":"A1","card_number":"4111111111111111"}`), nil)
+	require.NoError(t, err)
+	server.Close()
+
+	data, err := os.ReadFile(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-token")
+	assert.NotContains(t, string(data), "4111111111111111")
+
+	replayer, err := httpclient.NewRecorder(httpclient.RecorderConfig{
+		Mode:         httpclient.ModeReplay,
+		Path:         cassette,
+		RedactFields: []string{"card_number"},
+	})
+	require.NoError(t, err)
+
+	client = httpclient.NewClient(newRecorderTestConfig(), server.URL,
+		httpclient.WithTransportMiddleware(replayer.Wrap))
+
+	resp, err := client.Get(context.Background(), "/orders/42?view=full", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"id":42,"status":"full"}`, string(resp.Body))
+
+	resp, err = client.Post(context.Background(), "/orders", []byte(`{"card_number":"4000000000000002", "sku":"A1"}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestRecordAndReplayMultipart(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "upload.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("label")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(data)
+	}))
+
+	upload := func(client httpclient.Client, label string) (*httpclient.Response, error) {
+		body, err := httpclient.NewMultipartBuilder().
+			AddField("order_id", "42").
+			AddReader("label", "label.zpl", strings.NewReader(label)).
+			Build()
+		require.NoError(t, err)
+		defer body.Close()
+		return client.Do(context.Background(), http.MethodPost, "/upload", body, nil)
+	}
+
+	recorder, err := httpclient.NewRecorder(httpclient.RecorderConfig{Mode: httpclient.ModeRecord, Path: cassette})
+	require.NoError(t, err)
+	resp, err := upload(httpclient.NewClient(newRecorderTestConfig(), server.URL,
+		httpclient.WithTransportMiddleware(recorder.Wrap)), "^XA^XZ")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	server.Close()
+
+	replayer, err := httpclient.NewRecorder(httpclient.RecorderConfig{Mode: httpclient.ModeReplay, Path: cassette})
+	require.NoError(t, err)
+	client := httpclient.NewClient(newRecorderTestConfig(), server.URL,
+		httpclient.WithTransportMiddleware(replayer.Wrap))
+
+	// A rebuilt body has a new boundary but the same parts
+	resp, err = upload(client, "^XA^XZ")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "^XA^XZ", string(resp.Body))
+
+	_, err = upload(client, "^XA^FO50^XZ")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no interaction")
+}
+
+func TestReplayUnmatchedRequest(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "empty.json")
+	require.NoError(t, os.WriteFile(cassette, []byte(`{"interactions":[
+		{"request":{"method":"GET","path":"/orders/42","query":{"view":["full"]}},"response":{"statusCode":200,"body":"ok"}}
+	]}`), 0644))
+
+	replayer, err := httpclient.NewRecorder(httpclient.RecorderConfig{
+		Mode: httpclient.ModeReplay,
+		Path: cassette,
+	})
+	require.NoError(t, err)
+
+	client := httpclient.NewClient(newRecorderTestConfig(), "http://partner.invalid",
+		httpclient.WithTransportMiddleware(replayer.Wrap))
+
+	resp, err := client.Get(context.Background(), "/orders/42?view=full", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ok"), resp.Body)
+
+	_, err = client.Get(context.Background(), "/orders/42?view=summary", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no interaction")
+	assert.Contains(t, err.Error(), "GET /orders/42?view=summary")
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := httpclient.NewRecorder(httpclient.RecorderConfig{
+		Mode: httpclient.ModeReplay,
+		Path: filepath.Join(t.TempDir(), "missing.json"),
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"order-system/pkg/infra/config"
+)
+
+// NewTransport builds an HTTP transport from configuration. Unset values
+// default to the settings the client has always used.
+func NewTransport(cfg config.TransportConfig) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   durationOrDefault(cfg.DialTimeout, 30*time.Second),
+		KeepAlive: durationOrDefault(cfg.KeepAlive, 30*time.Second),
+	}
+
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        intOrDefault(cfg.MaxIdleConns, 100),
+		MaxIdleConnsPerHost: intOrDefault(cfg.MaxIdleConnsPerHost, 100),
+		MaxConnsPerHost:     intOrDefault(cfg.MaxConnsPerHost, 100),
+		IdleConnTimeout:     durationOrDefault(cfg.IdleConnTimeout, 90*time.Second),
+		TLSHandshakeTimeout: durationOrDefault(cfg.TLSHandshakeTimeout, 10*time.Second),
+	}
+
+	// HTTP/2 is only attempted when explicitly enabled
+	if cfg.EnableHTTP2 != nil && *cfg.EnableHTTP2 {
+		transport.ForceAttemptHTTP2 = true
+	} else if cfg.EnableHTTP2 != nil {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// newTLSConfig builds the TLS settings including custom CAs and client certificates
+func newTLSConfig(cfg config.TransportConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	switch cfg.MinTLSVersion {
+	case "":
+	case "1.0":
+		tlsConfig.MinVersion = tls.VersionTLS10
+	case "1.1":
+		tlsConfig.MinVersion = tls.VersionTLS11
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("invalid minimum tls version: %s", cfg.MinTLSVersion)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// durationOrDefault returns d, or def if d is not set
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+// intOrDefault returns n, or def if n is not set
+func intOrDefault(n, def int) int {
+	if n > 0 {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,179 @@
+package http_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	httpclient "order-system/pkg/infra/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeServerCA writes the TLS test server's certificate as a CA bundle
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+// writeClientCert generates a self-signed client certificate and key
+func writeClientCert(t *testing.T) (certPath, keyPath string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "order-system"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPath = filepath.Join(dir, "client.pem")
+	keyPath = filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath, cert
+}
+
+func newTransportTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 5 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	return cfg
+}
+
+func TestTransportCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTransportTestConfig()
+	noRetry := &httpclient.RequestOption{MaxBodySize: 1024}
+
+	_, err := httpclient.NewClient(cfg, server.URL).Get(context.Background(), "/", noRetry)
+	require.Error(t, err)
+
+	cfg.HTTPClient.Transport.CAFile = writeServerCA(t, server)
+	resp, err := httpclient.NewClient(cfg, server.URL).Get(context.Background(), "/", noRetry)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransportMutualTLSForNamedClient(t *testing.T) {
+	certPath, keyPath, clientCert := writeClientCert(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Len(t, r.TLS.PeerCertificates, 1)
+		assert.Equal(t, "order-system", r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.WriteHeader(http.StatusOK)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	cfg := newTransportTestConfig()
+	cfg.HTTPClient.Transport.CAFile = writeServerCA(t, server)
+	cfg.HTTPClient.Clients = map[string]config.TransportConfig{
+		"partner": {CertFile: certPath, KeyFile: keyPath},
+	}
+	noRetry := &httpclient.RequestOption{MaxBodySize: 1024}
+
+	_, err := httpclient.NewClient(cfg, server.URL).Get(context.Background(), "/", noRetry)
+	require.Error(t, err)
+
+	client := httpclient.NewClient(cfg, server.URL, httpclient.WithName("partner"))
+	resp, err := client.Get(context.Background(), "/", noRetry)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransportMinTLSVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	cfg := newTransportTestConfig()
+	cfg.HTTPClient.Transport.CAFile = writeServerCA(t, server)
+	cfg.HTTPClient.Transport.MinTLSVersion = "1.3"
+
+	_, err := httpclient.NewClient(cfg, server.URL).Get(context.Background(), "/", &httpclient.RequestOption{MaxBodySize: 1024})
+	require.Error(t, err)
+}
+
+func TestInvalidTransportConfig(t *testing.T) {
+	cfg := newTransportTestConfig()
+	cfg.HTTPClient.Transport.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+
+	_, err := httpclient.NewClient(cfg, "https://example.com").Get(context.Background(), "/", nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid transport configuration")
+}
+
+func TestNewTransportDefaults(t *testing.T) {
+	disabled := false
+	transport, err := httpclient.NewTransport(config.TransportConfig{
+		MaxConnsPerHost: 10,
+		EnableHTTP2:     &disabled,
+		ProxyURL:        "http://proxy.internal:3128",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxConnsPerHost)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://partner.example.com", nil)
+	proxy, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxy.Host)
+}
+
+func TestNewTransportHTTP2(t *testing.T) {
+	transport, err := httpclient.NewTransport(config.TransportConfig{})
+	require.NoError(t, err)
+	assert.False(t, transport.ForceAttemptHTTP2)
+
+	enabled := true
+	transport, err = httpclient.NewTransport(config.TransportConfig{EnableHTTP2: &enabled})
+	require.NoError(t, err)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Nil(t, transport.TLSNextProto)
+}
@@ -2,6 +2,8 @@ package http
 
 import (
 	"context"
+	"io"
+	"net/http"
 	"time"
 )
 
@@ -12,6 +14,19 @@ type RequestOption struct {
 	RetryInterval time.Duration
 	MaxBodySize   int64
 	Headers       map[string]string
+
+	// CompressThreshold gzips request bodies larger than this many bytes; 0 disables it
+	CompressThreshold int64
+	// DisableCompression stops the client from negotiating compressed responses
+	DisableCompression bool
+
+	// HedgeDelay sends a second GET attempt if the first has not completed within it; 0 disables hedging
+	HedgeDelay time.Duration
+	// HedgePercentile derives the hedge delay from observed latency (e.g. 0.95) once enough samples exist
+	HedgePercentile float64
+
+	// Route is the route template of the request, e.g. "/orders/{id}", used to label metrics
+	Route string
 }
 
 // Response represents an HTTP response
@@ -22,6 +37,12 @@ type Response struct {
 	Duration   time.Duration
 }
 
+// Stats represents client statistics
+type Stats struct {
+	HedgedRequests int64
+	HedgeWins      int64
+}
+
 // Error represents an HTTP error
 type Error struct {
 	StatusCode int
@@ -36,10 +57,76 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// RequestBody represents a request body that can be replayed on every attempt
+type RequestBody interface {
+	// ContentType returns the Content-Type of the body, or "" to leave it unset
+	ContentType() string
+	// Open returns a new reader positioned at the start of the body
+	Open() (io.ReadCloser, error)
+}
+
+// CacheEntry represents a cached response with its validators
+type CacheEntry struct {
+	Response     *Response
+	ETag         string
+	LastModified string
+	Expires      time.Time
+	// Vary holds the values of the request headers named by the Vary
+	// header of the response; the entry serves only matching requests
+	Vary map[string]string
+}
+
+// CacheStats represents response cache statistics
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// CacheStore defines the storage behind the response cache
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// AuthProvider adds credentials to outgoing requests
+type AuthProvider interface {
+	// Authenticate adds credentials to the request before it is sent
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// Refresher is implemented by auth providers whose credentials can be renewed
+// after the server rejects them with 401 Unauthorized
+type Refresher interface {
+	// Refresh discards cached credentials and obtains new ones
+	Refresh(ctx context.Context) error
+}
+
+// AttemptInfo describes the request attempt passed to interceptors
+type AttemptInfo struct {
+	Client  string
+	Route   string
+	Attempt int
+	// Body is the request body before compression; nil without a body
+	Body RequestBody
+}
+
+// Invoker performs a single request attempt
+type Invoker func(req *http.Request) (*Response, error)
+
+// Interceptor wraps every request attempt made by the client. It must call
+// next to send the request and may inspect or replace the result.
+type Interceptor func(req *http.Request, info *AttemptInfo, next Invoker) (*Response, error)
+
+// TransportMiddleware wraps the transport that sends requests over the network
+type TransportMiddleware func(next http.RoundTripper) http.RoundTripper
+
 // Client interface defines the HTTP client behavior
 type Client interface {
+	Do(ctx context.Context, method, url string, body RequestBody, opt *RequestOption) (*Response, error)
 	Get(ctx context.Context, url string, opt *RequestOption) (*Response, error)
 	Post(ctx context.Context, url string, body []byte, opt *RequestOption) (*Response, error)
 	Put(ctx context.Context, url string, body []byte, opt *RequestOption) (*Response, error)
 	Delete(ctx context.Context, url string, opt *RequestOption) (*Response, error)
+	Stats() Stats
 }
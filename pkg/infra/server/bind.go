@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+)
+
+// Binder decodes and validates JSON request bodies
+type Binder struct {
+	maxSize int64
+}
+
+// NewBinder creates a binder enforcing config.HTTP.MaxRequestSize
+func NewBinder(cfg *config.Config) *Binder {
+	return &Binder{maxSize: cfg.HTTP.MaxRequestSize}
+}
+
+// BodyLimit rejects requests whose body exceeds maxSize bytes with 413.
+// Bodies without a Content-Length fail when the limit is reached while
+// reading. A non-positive maxSize disables the limit.
+func BodyLimit(maxSize int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		if maxSize <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxSize {
+				WriteError(w, r, tooLarge(maxSize))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Bind decodes the JSON body of r into v and validates it. Bodies larger than
+// the limit fail with PAYLOAD_TOO_LARGE, malformed bodies and unknown fields
+// with BAD_REQUEST and rule violations with VALIDATION_ERROR, listing every
+// failed field. The error can be passed to WriteError as is.
+func (b *Binder) Bind(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if b.maxSize > 0 {
+		if r.ContentLength > b.maxSize {
+			return tooLarge(b.maxSize)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, b.maxSize)
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return decodeError(err)
+		}
+		return apperrors.New(CodeBadRequest, "request body must contain a single JSON value")
+	}
+
+	if err := Validate(v); err != nil {
+		return apperrors.Wrap(err, CodeValidation, "request validation failed")
+	}
+	return nil
+}
+
+// decodeError converts a JSON decoding error into a client error
+func decodeError(err error) *apperrors.Error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return tooLarge(maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return apperrors.New(CodeBadRequest, "request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperrors.New(CodeBadRequest, "request body contains malformed JSON")
+	case errors.As(err, &syntaxErr):
+		return apperrors.New(CodeBadRequest, fmt.Sprintf("request body contains malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		return apperrors.New(CodeBadRequest, fmt.Sprintf("field %s must be of type %s", typeErr.Field, typeErr.Type))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		return apperrors.New(CodeBadRequest, "request body contains unknown field "+strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return apperrors.Wrap(err, CodeBadRequest, "failed to read request body")
+	}
+}
+
+// tooLarge returns the error of a body exceeding limit bytes
+func tooLarge(limit int64) *apperrors.Error {
+	return apperrors.New(CodeTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
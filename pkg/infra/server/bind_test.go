@@ -0,0 +1,110 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBindHandler(maxSize int64) http.Handler {
+	cfg := &config.Config{}
+	cfg.HTTP.MaxRequestSize = maxSize
+	binder := server.NewBinder(cfg)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req createOrder
+		if err := binder.Bind(w, r, &req); err != nil {
+			server.WriteError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+}
+
+func post(h http.Handler, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+	return rec
+}
+
+func problemBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestBindValid(t *testing.T) {
+	rec := post(newBindHandler(1024), `{"customerId":"c-1","items":[{"sku":"ABC-1","quantity":1}]}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestBindValidationErrors(t *testing.T) {
+	rec := post(newBindHandler(1024), `{"currency":"GBP","items":[{"sku":"x","quantity":100}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	body := problemBody(t, rec)
+	assert.Equal(t, server.CodeValidation, body["code"])
+	errs := body["errors"].([]interface{})
+	assert.Len(t, errs, 4)
+	assert.Equal(t, map[string]interface{}{
+		"field":   "customerId",
+		"rule":    "required",
+		"message": "is required",
+	}, errs[0])
+}
+
+func TestBindRejectsInvalidBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"unknown field", `{"customerId":"c-1","discount":100}`, `unknown field "discount"`},
+		{"malformed", `{"customerId":`, "malformed JSON"},
+		{"wrong type", `{"customerId":42}`, "field customerId must be of type string"},
+		{"empty", ``, "request body is empty"},
+		{"trailing data", `{"customerId":"c-1"} {}`, "single JSON value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(newBindHandler(1024), tt.body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, problemBody(t, rec)["detail"], tt.message)
+		})
+	}
+}
+
+func TestBindBodyTooLarge(t *testing.T) {
+	body := `{"customerId":"` + strings.Repeat("x", 100) + `"}`
+
+	rec := post(newBindHandler(32), body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, server.CodeTooLarge, problemBody(t, rec)["code"])
+
+	// Without Content-Length the limit applies while reading
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	newBindHandler(32).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestBodyLimit(t *testing.T) {
+	h := server.BodyLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	assert.Equal(t, http.StatusNoContent, post(h, "small").Code)
+
+	rec := post(h, "this body is too large")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, server.ContentTypeProblem, rec.Header().Get("Content-Type"))
+}
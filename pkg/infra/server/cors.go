@@ -0,0 +1,196 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+)
+
+// Security header defaults
+const (
+	defaultFrameOptions          = "DENY"
+	defaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	defaultReferrerPolicy        = "no-referrer"
+)
+
+// defaultCORSMethods are the methods allowed when none are configured
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// CORS handles cross-origin requests according to cfg. Preflight requests
+// are answered directly, with 403 if the origin, method or headers are not
+// allowed; actual requests from allowed origins get the CORS response
+// headers. It must run before routing decisions such as 405 are made, so
+// it belongs on the Router.
+func CORS(cfg config.CORSConfig) Middleware {
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	allowMethods := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		allowMethods[strings.ToUpper(m)] = true
+	}
+	allowHeaders := make(map[string]bool, len(cfg.AllowedHeaders))
+	anyHeader := false
+	for _, h := range cfg.AllowedHeaders {
+		if h == "*" {
+			anyHeader = true
+		}
+		allowHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(cfg.AllowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, wildcard := matchOrigin(cfg.AllowedOrigins, origin)
+			if !preflight {
+				if allowed {
+					setAllowOrigin(w, cfg, origin, wildcard)
+					if len(cfg.ExposedHeaders) > 0 {
+						w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+					}
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed {
+				WriteError(w, r, apperrors.New(CodeForbidden, "origin "+origin+" is not allowed"))
+				return
+			}
+
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			if !allowMethods[method] {
+				WriteError(w, r, apperrors.New(CodeForbidden, "method "+method+" is not allowed"))
+				return
+			}
+			requested := splitHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+			for _, h := range requested {
+				if !anyHeader && !allowHeaders[http.CanonicalHeaderKey(h)] {
+					WriteError(w, r, apperrors.New(CodeForbidden, "header "+h+" is not allowed"))
+					return
+				}
+			}
+
+			setAllowOrigin(w, cfg, origin, wildcard)
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			if len(requested) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+			}
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge/time.Second)))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// setAllowOrigin sets the allowed origin and credentials headers. The "*"
+// origin is only sent for the wildcard entry; other origins are echoed.
+func setAllowOrigin(w http.ResponseWriter, cfg config.CORSConfig, origin string, wildcard bool) {
+	if wildcard {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// matchOrigin reports whether origin is allowed and whether it matched "*"
+func matchOrigin(allowed []string, origin string) (bool, bool) {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" {
+			return true, true
+		}
+		if pattern == origin {
+			return true, false
+		}
+
+		// https://*.example.com matches subdomains at any depth
+		scheme, domain, ok := strings.Cut(pattern, "*.")
+		if !ok || !strings.HasPrefix(origin, scheme) {
+			continue
+		}
+		host := strings.TrimPrefix(origin, scheme)
+		if strings.HasSuffix(host, "."+domain) && len(host) > len(domain)+1 && !strings.ContainsAny(host[:len(host)-len(domain)-1], "/:@") {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// splitHeaderList splits a comma-separated header value
+func splitHeaderList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// SecurityHeaders sets HSTS, X-Content-Type-Options, X-Frame-Options,
+// Content-Security-Policy and Referrer-Policy on every response
+func SecurityHeaders(cfg config.SecurityHeadersConfig) Middleware {
+	headers := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         headerOrDefault(cfg.FrameOptions, defaultFrameOptions),
+		"Content-Security-Policy": headerOrDefault(cfg.ContentSecurityPolicy, defaultContentSecurityPolicy),
+		"Referrer-Policy":         headerOrDefault(cfg.ReferrerPolicy, defaultReferrerPolicy),
+	}
+	if cfg.HSTSMaxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge/time.Second))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range headers {
+				if v != "" {
+					w.Header().Set(k, v)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// headerOrDefault returns value, def if it is empty, or "" if it is "-"
+func headerOrDefault(value, def string) string {
+	switch value {
+	case "":
+		return def
+	case "-":
+		return ""
+	default:
+		return value
+	}
+}
@@ -0,0 +1,155 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter(cfg config.CORSConfig) *server.Router {
+	router := server.NewRouter()
+	router.Use(server.CORS(cfg))
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("orders"))
+	})
+	return router
+}
+
+func corsRequest(h http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/orders", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSOrigins(t *testing.T) {
+	router := newCORSRouter(config.CORSConfig{
+		AllowedOrigins:   []string{"https://shop.example.org", "https://*.example.com"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://shop.example.org", true},
+		{"https://eu.shop.example.com", true},
+		{"https://www.example.com", true},
+		{"https://example.com", false},
+		{"http://www.example.com", false},
+		{"https://www.example.com:8443", false},
+		{"https://evilexample.com", false},
+		{"https://shop.example.org.evil.net", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			rec := corsRequest(router, http.MethodGet, tt.origin, nil)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+			if tt.allowed {
+				assert.Equal(t, tt.origin, rec.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+				assert.Equal(t, "X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+
+	rec := corsRequest(router, http.MethodGet, "", nil)
+	assert.Equal(t, "orders", rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	router := newCORSRouter(config.CORSConfig{AllowedOrigins: []string{"*"}})
+
+	rec := corsRequest(router, http.MethodGet, "https://anywhere.example", nil)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter(config.CORSConfig{
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "Idempotency-Key"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	rec := corsRequest(router, http.MethodOptions, "https://shop.example.com", map[string]string{
+		"Access-Control-Request-Method":  http.MethodDelete,
+		"Access-Control-Request-Headers": "authorization, idempotency-key",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://shop.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, DELETE", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "authorization, idempotency-key", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.ElementsMatch(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rec.Header().Values("Vary"))
+	assert.Empty(t, rec.Body.String())
+
+	rejected := []map[string]string{
+		{"Access-Control-Request-Method": http.MethodPut},
+		{"Access-Control-Request-Method": http.MethodGet, "Access-Control-Request-Headers": "X-Debug"},
+	}
+	for _, headers := range rejected {
+		rec = corsRequest(router, http.MethodOptions, "https://shop.example.com", headers)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	rec = corsRequest(router, http.MethodOptions, "https://evil.example.net", map[string]string{
+		"Access-Control-Request-Method": http.MethodGet,
+	})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// OPTIONS without a preflight header is routed normally
+	rec = corsRequest(router, http.MethodOptions, "https://shop.example.com", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestCORSDisabled(t *testing.T) {
+	router := newCORSRouter(config.CORSConfig{})
+
+	rec := corsRequest(router, http.MethodGet, "https://shop.example.com", nil)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Vary"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := serve(server.SecurityHeaders(config.SecurityHeadersConfig{})(ok), http.MethodGet, "/")
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", rec.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"))
+
+	rec = serve(server.SecurityHeaders(config.SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           true,
+		FrameOptions:          "SAMEORIGIN",
+		ContentSecurityPolicy: "default-src 'self'",
+		ReferrerPolicy:        "-",
+	})(ok), http.MethodGet, "/")
+	assert.Equal(t, "max-age=31536000; includeSubDomains; preload", rec.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "SAMEORIGIN", rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'self'", rec.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, rec.Header().Values("Referrer-Policy"))
+}
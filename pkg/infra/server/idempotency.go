@@ -0,0 +1,394 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"order-system/pkg/infra/database"
+	apperrors "order-system/pkg/infra/errors"
+)
+
+// Idempotency headers
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// Idempotency defaults
+const (
+	defaultIdempotencyTTL   = 24 * time.Hour
+	defaultIdempotencyLease = time.Minute
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencySchema creates the MySQL table used by the SQL idempotency store
+const IdempotencySchema = `CREATE TABLE IF NOT EXISTS idempotency_keys (
+	scope VARCHAR(255) NOT NULL,
+	idempotency_key VARCHAR(255) NOT NULL,
+	fingerprint CHAR(64) NOT NULL,
+	owner CHAR(32) NOT NULL,
+	status_code INT NULL,
+	response_headers TEXT NULL,
+	response_body MEDIUMBLOB NULL,
+	expires_at DATETIME(6) NOT NULL,
+	PRIMARY KEY (scope, idempotency_key),
+	KEY idx_idempotency_keys_expires_at (expires_at)
+)`
+
+// ErrIdempotencyKeyLost is returned by IdempotencyStore when a record is no
+// longer owned by the request that claimed it
+var ErrIdempotencyKeyLost = errors.New("idempotency key was taken over")
+
+// IdempotencyRecord represents a request stored under an idempotency key.
+// StatusCode is 0 while the request is in progress; ExpiresAt is then the
+// end of its lease. Owner identifies the claim of the request holding the
+// key, which changes when a lapsed lease is taken over.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Owner       string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore persists idempotency records
+type IdempotencyStore interface {
+	// Create stores a new in-progress record and reports false if the key exists
+	Create(ctx context.Context, rec *IdempotencyRecord) (bool, error)
+	// Get returns the record of a key, or nil if there is none
+	Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error)
+	// Takeover replaces the record of a key that expired before now and
+	// reports false if there is none; concurrent takeovers of a record must
+	// succeed at most once
+	Takeover(ctx context.Context, rec *IdempotencyRecord, now time.Time) (bool, error)
+	// Complete stores the response and expiry of a record still owned by
+	// rec.Owner, or returns ErrIdempotencyKeyLost
+	Complete(ctx context.Context, rec *IdempotencyRecord) error
+	// Delete removes a record still owned by rec.Owner, or returns
+	// ErrIdempotencyKeyLost
+	Delete(ctx context.Context, rec *IdempotencyRecord) error
+	// DeleteExpired removes records that expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// IdempotencyConfig represents the settings of the idempotency middleware
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// TTL is how long keys are remembered; defaults to 24h
+	TTL time.Duration
+	// Lease is how long a request in progress holds its key, after which a
+	// retry takes it over, e.g. when the process crashed. It should exceed
+	// the request timeout. Defaults to 1m.
+	Lease time.Duration
+	// Required rejects requests without an Idempotency-Key
+	Required bool
+	// Scope partitions keys, e.g. per client; defaults to method and route
+	Scope func(r *http.Request) string
+}
+
+// Idempotency replays the stored response of requests repeating an
+// Idempotency-Key. A repeat while the first request is still in progress
+// fails with 409 and a key reused for a different request with 422.
+// Server errors are not stored, so the request can be retried. Request
+// bodies are fingerprinted, so BodyLimit should run first.
+func Idempotency(cfg IdempotencyConfig) Middleware {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultIdempotencyTTL
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultIdempotencyLease
+	}
+	if cfg.Scope == nil {
+		cfg.Scope = func(r *http.Request) string {
+			return r.Method + " " + RouteTemplate(r)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				if cfg.Required {
+					WriteError(w, r, apperrors.New(CodeBadRequest, "Idempotency-Key header is required"))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				WriteError(w, r, apperrors.New(CodeBadRequest, "Idempotency-Key header is too long"))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				WriteError(w, r, decodeError(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			rec := &IdempotencyRecord{
+				Scope:       cfg.Scope(r),
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				Owner:       newRequestID(),
+				ExpiresAt:   time.Now().Add(cfg.Lease),
+			}
+			existing, err := acquireKey(r.Context(), cfg.Store, rec)
+			if err != nil {
+				WriteError(w, r, err)
+				return
+			}
+			if existing != nil {
+				replay(w, r, rec, existing)
+				return
+			}
+
+			capture := &captureWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				// Release the key if the handler failed so that it can be
+				// retried, unless a retry already took it over
+				if !completed {
+					cfg.Store.Delete(context.WithoutCancel(r.Context()), rec)
+				}
+			}()
+			next.ServeHTTP(capture, r)
+
+			if capture.Status() >= http.StatusInternalServerError {
+				return
+			}
+			rec.StatusCode = capture.Status()
+			rec.Header = capture.header
+			rec.Body = capture.body.Bytes()
+			rec.ExpiresAt = time.Now().Add(cfg.TTL)
+			completed = cfg.Store.Complete(context.WithoutCancel(r.Context()), rec) == nil
+		})
+	}
+}
+
+// acquireKey creates the record of a key, taking over expired records and
+// lapsed leases. If the key exists and has not expired, its record is
+// returned instead.
+func acquireKey(ctx context.Context, store IdempotencyStore, rec *IdempotencyRecord) (*IdempotencyRecord, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := store.Create(ctx, rec)
+		if err != nil {
+			return nil, apperrors.Wrap(err, CodeInternal, "failed to store idempotency key")
+		}
+		if created {
+			return nil, nil
+		}
+
+		existing, err := store.Get(ctx, rec.Scope, rec.Key)
+		if err != nil {
+			return nil, apperrors.Wrap(err, CodeInternal, "failed to load idempotency key")
+		}
+		if existing == nil {
+			continue
+		}
+		now := time.Now()
+		if now.Before(existing.ExpiresAt) {
+			return existing, nil
+		}
+		taken, err := store.Takeover(ctx, rec, now)
+		if err != nil {
+			return nil, apperrors.Wrap(err, CodeInternal, "failed to take over idempotency key")
+		}
+		if taken {
+			return nil, nil
+		}
+	}
+	return nil, apperrors.New(CodeConflict, "idempotency key is contended")
+}
+
+// ExpireIdempotencyKeys deletes expired keys from store every interval
+// until ctx is done
+func ExpireIdempotencyKeys(ctx context.Context, store IdempotencyStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			store.DeleteExpired(ctx, now)
+		}
+	}
+}
+
+// replay answers a repeated request from the existing record
+func replay(w http.ResponseWriter, r *http.Request, rec, existing *IdempotencyRecord) {
+	switch {
+	case existing.Fingerprint != rec.Fingerprint:
+		WriteError(w, r, apperrors.New(CodeUnprocessable, "Idempotency-Key was already used for a different request"))
+	case existing.StatusCode == 0:
+		WriteError(w, r, apperrors.New(CodeConflict, "a request with this Idempotency-Key is in progress"))
+	default:
+		for k, v := range existing.Header {
+			w.Header()[k] = append([]string(nil), v...)
+		}
+		w.Header().Set(HeaderIdempotentReplayed, "true")
+		w.WriteHeader(existing.StatusCode)
+		w.Write(existing.Body)
+	}
+}
+
+// fingerprint hashes the method, URI and body of a request
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter records a response while writing it
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.ResponseWriter.Header().Clone()
+		w.header.Del(HeaderRequestID)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Status returns the response status, defaulting to 200
+func (w *captureWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// sqlIdempotencyStore implements IdempotencyStore on MySQL
+type sqlIdempotencyStore struct {
+	db database.Database
+}
+
+// NewSQLIdempotencyStore creates a store on the idempotency_keys table, see
+// IdempotencySchema
+func NewSQLIdempotencyStore(db database.Database) IdempotencyStore {
+	return &sqlIdempotencyStore{db: db}
+}
+
+// Create implements IdempotencyStore.Create
+func (s *sqlIdempotencyStore) Create(ctx context.Context, rec *IdempotencyRecord) (bool, error) {
+	_, err := s.db.Exec(ctx,
+		"INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, owner, expires_at) VALUES (?, ?, ?, ?, ?)",
+		rec.Scope, rec.Key, rec.Fingerprint, rec.Owner, rec.ExpiresAt)
+	if database.IsDuplicate(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get implements IdempotencyStore.Get
+func (s *sqlIdempotencyStore) Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error) {
+	var (
+		rec     = &IdempotencyRecord{Scope: scope, Key: key}
+		status  sql.NullInt64
+		headers sql.NullString
+	)
+	err := s.db.QueryRow(ctx,
+		"SELECT fingerprint, status_code, response_headers, response_body, expires_at FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?",
+		scope, key).Scan(&rec.Fingerprint, &status, &headers, &rec.Body, &rec.ExpiresAt)
+	if database.IsNoRows(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rec.StatusCode = int(status.Int64)
+	if headers.Valid && headers.String != "" {
+		if err := json.Unmarshal([]byte(headers.String), &rec.Header); err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
+
+// Takeover implements IdempotencyStore.Takeover. The expiry check runs
+// under the row lock, so only one of concurrent takeovers updates the row.
+func (s *sqlIdempotencyStore) Takeover(ctx context.Context, rec *IdempotencyRecord, now time.Time) (bool, error) {
+	result, err := s.db.Exec(ctx,
+		"UPDATE idempotency_keys SET fingerprint = ?, owner = ?, status_code = NULL, response_headers = NULL, response_body = NULL, expires_at = ? WHERE scope = ? AND idempotency_key = ? AND expires_at < ?",
+		rec.Fingerprint, rec.Owner, rec.ExpiresAt, rec.Scope, rec.Key, now)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Complete implements IdempotencyStore.Complete
+func (s *sqlIdempotencyStore) Complete(ctx context.Context, rec *IdempotencyRecord) error {
+	headers, err := json.Marshal(rec.Header)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(ctx,
+		"UPDATE idempotency_keys SET status_code = ?, response_headers = ?, response_body = ?, expires_at = ? WHERE scope = ? AND idempotency_key = ? AND owner = ?",
+		rec.StatusCode, string(headers), rec.Body, rec.ExpiresAt, rec.Scope, rec.Key, rec.Owner)
+	return ownedResult(result, err)
+}
+
+// Delete implements IdempotencyStore.Delete
+func (s *sqlIdempotencyStore) Delete(ctx context.Context, rec *IdempotencyRecord) error {
+	result, err := s.db.Exec(ctx,
+		"DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? AND owner = ?",
+		rec.Scope, rec.Key, rec.Owner)
+	return ownedResult(result, err)
+}
+
+// ownedResult returns ErrIdempotencyKeyLost if a statement on an owned
+// record matched no row
+func ownedResult(result *database.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotencyKeyLost
+	}
+	return nil
+}
+
+// DeleteExpired implements IdempotencyStore.DeleteExpired
+func (s *sqlIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ?", now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,404 @@
+package server_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/database"
+	"order-system/pkg/infra/server"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore implements server.IdempotencyStore in memory
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]server.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]server.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Create(ctx context.Context, rec *server.IdempotencyRecord) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[rec.Scope+"|"+rec.Key]; ok {
+		return false, nil
+	}
+	s.records[rec.Scope+"|"+rec.Key] = *rec
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Get(ctx context.Context, scope, key string) (*server.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[scope+"|"+key]
+	if !ok {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+func (s *memoryIdempotencyStore) Takeover(ctx context.Context, rec *server.IdempotencyRecord, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.records[rec.Scope+"|"+rec.Key]
+	if !ok || !existing.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	s.records[rec.Scope+"|"+rec.Key] = *rec
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, rec *server.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[rec.Scope+"|"+rec.Key].Owner != rec.Owner {
+		return server.ErrIdempotencyKeyLost
+	}
+	s.records[rec.Scope+"|"+rec.Key] = *rec
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Delete(ctx context.Context, rec *server.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.records[rec.Scope+"|"+rec.Key]
+	if !ok || existing.Owner != rec.Owner {
+		return server.ErrIdempotencyKeyLost
+	}
+	delete(s.records, rec.Scope+"|"+rec.Key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for k, rec := range s.records {
+		if rec.ExpiresAt.Before(now) {
+			delete(s.records, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func newIdempotentRouter(store server.IdempotencyStore, handler http.HandlerFunc) *server.Router {
+	router := server.NewRouter()
+	router.Use(server.Idempotency(server.IdempotencyConfig{Store: store, TTL: time.Hour}))
+	router.Post("/orders", handler)
+	return router
+}
+
+func postOrder(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(server.HeaderIdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var created int32
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		id := atomic.AddInt32(&created, 1)
+		w.Header().Set("Location", fmt.Sprintf("/orders/%d", id))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, id)
+	})
+
+	first := postOrder(router, "key-1", `{"sku":"ABC-1"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(server.HeaderIdempotentReplayed))
+
+	second := postOrder(router, "key-1", `{"sku":"ABC-1"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(server.HeaderIdempotentReplayed))
+	assert.Equal(t, "/orders/1", second.Header().Get("Location"))
+	assert.Equal(t, `{"id":1}`, second.Body.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&created))
+
+	// Requests without a key or with another key are not deduplicated
+	postOrder(router, "", `{"sku":"ABC-1"}`)
+	postOrder(router, "key-2", `{"sku":"ABC-1"}`)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&created))
+}
+
+func TestIdempotencyDifferentBody(t *testing.T) {
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusCreated, postOrder(router, "key-1", `{"sku":"ABC-1"}`).Code)
+
+	rec := postOrder(router, "key-1", `{"sku":"ABC-2"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "different request")
+}
+
+func TestIdempotencyConcurrentDuplicate(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan int)
+	go func() { done <- postOrder(router, "key-1", `{}`).Code }()
+	<-started
+
+	rec := postOrder(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "in progress")
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, <-done)
+}
+
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	var calls int32
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, postOrder(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, postOrder(router, "key-1", `{}`).Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var calls int32
+	router := server.NewRouter()
+	router.Use(server.Idempotency(server.IdempotencyConfig{Store: store, TTL: 20 * time.Millisecond, Required: true}))
+	router.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusBadRequest, postOrder(router, "", `{}`).Code)
+
+	postOrder(router, "key-1", `{}`)
+	postOrder(router, "key-1", `{}`)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	time.Sleep(30 * time.Millisecond)
+	postOrder(router, "key-1", `{}`)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	time.Sleep(30 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.ExpireIdempotencyKeys(ctx, store, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		rec, _ := store.Get(context.Background(), "POST /orders", "key-1")
+		return rec == nil
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+}
+
+// crashingIdempotencyStore loses the writes of requests ending while
+// crashed is set, as if their process had crashed
+type crashingIdempotencyStore struct {
+	*memoryIdempotencyStore
+	crashed atomic.Bool
+}
+
+func (s *crashingIdempotencyStore) Complete(ctx context.Context, rec *server.IdempotencyRecord) error {
+	if s.crashed.Load() {
+		return nil
+	}
+	return s.memoryIdempotencyStore.Complete(ctx, rec)
+}
+
+func (s *crashingIdempotencyStore) Delete(ctx context.Context, rec *server.IdempotencyRecord) error {
+	if s.crashed.Load() {
+		return nil
+	}
+	return s.memoryIdempotencyStore.Delete(ctx, rec)
+}
+
+func TestIdempotencyLapsedLease(t *testing.T) {
+	store := &crashingIdempotencyStore{memoryIdempotencyStore: newMemoryIdempotencyStore()}
+	var calls int32
+	router := server.NewRouter()
+	router.Use(server.Idempotency(server.IdempotencyConfig{Store: store, TTL: time.Hour, Lease: 20 * time.Millisecond}))
+	router.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	store.crashed.Store(true)
+	postOrder(router, "key-1", `{}`)
+	store.crashed.Store(false)
+	assert.Equal(t, http.StatusConflict, postOrder(router, "key-1", `{}`).Code)
+
+	time.Sleep(30 * time.Millisecond)
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = postOrder(router, "key-1", `{}`).Code
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Contains(t, codes, http.StatusCreated)
+
+	// The completed response is kept for the TTL rather than the lease
+	time.Sleep(30 * time.Millisecond)
+	rec := postOrder(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(server.HeaderIdempotentReplayed))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyOverrunLease(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	var calls int32
+	router := server.NewRouter()
+	router.Use(server.Idempotency(server.IdempotencyConfig{Store: store, TTL: time.Hour, Lease: 20 * time.Millisecond}))
+	router.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			// The first request outlives its lease
+			time.Sleep(60 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%d", n)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postOrder(router, "key-1", `{}`)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, "2", postOrder(router, "key-1", `{}`).Body.String())
+	<-done
+
+	// The overrunning request neither overwrote nor released the new claim
+	rec := postOrder(router, "key-1", `{}`)
+	assert.Equal(t, "true", rec.Header().Get(server.HeaderIdempotentReplayed))
+	assert.Equal(t, "2", rec.Body.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// scriptedDB implements database.Database with scripted results
+type scriptedDB struct {
+	database.Database
+	execErr error
+	// unmatched makes statements affect no rows
+	unmatched bool
+	row       func(dest ...interface{}) error
+	queries   []string
+}
+
+func (d *scriptedDB) Exec(ctx context.Context, query string, args ...interface{}) (*database.Result, error) {
+	d.queries = append(d.queries, query)
+	if d.execErr != nil {
+		return nil, d.execErr
+	}
+	if d.unmatched {
+		return &database.Result{}, nil
+	}
+	return &database.Result{RowsAffected: 1}, nil
+}
+
+type scriptedRow func(dest ...interface{}) error
+
+func (r scriptedRow) Scan(dest ...interface{}) error { return r(dest...) }
+
+func (d *scriptedDB) QueryRow(ctx context.Context, query string, args ...interface{}) database.Row {
+	d.queries = append(d.queries, query)
+	return scriptedRow(d.row)
+}
+
+func TestSQLIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	db := &scriptedDB{}
+	store := server.NewSQLIdempotencyStore(db)
+	rec := &server.IdempotencyRecord{Scope: "POST /orders", Key: "key-1", Fingerprint: "abc", ExpiresAt: time.Now()}
+
+	created, err := store.Create(ctx, rec)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Contains(t, db.queries[0], "INSERT INTO idempotency_keys")
+
+	db.execErr = &database.Error{Operation: "exec", Err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}}
+	created, err = store.Create(ctx, rec)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	db.execErr = &database.Error{Operation: "exec", Err: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout"}}
+	_, err = store.Create(ctx, rec)
+	assert.Error(t, err)
+
+	db.execErr = nil
+	taken, err := store.Takeover(ctx, rec, time.Now())
+	require.NoError(t, err)
+	assert.True(t, taken)
+	assert.Contains(t, db.queries[len(db.queries)-1], "expires_at < ?")
+
+	require.NoError(t, store.Complete(ctx, rec))
+	assert.Contains(t, db.queries[len(db.queries)-1], "owner = ?")
+	require.NoError(t, store.Delete(ctx, rec))
+	assert.Contains(t, db.queries[len(db.queries)-1], "owner = ?")
+
+	// Records taken over by another request are left alone
+	db.unmatched = true
+	assert.ErrorIs(t, store.Complete(ctx, rec), server.ErrIdempotencyKeyLost)
+	assert.ErrorIs(t, store.Delete(ctx, rec), server.ErrIdempotencyKeyLost)
+	db.unmatched = false
+
+	db.row = func(dest ...interface{}) error { return sql.ErrNoRows }
+	missing, err := store.Get(ctx, "POST /orders", "key-1")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	expires := time.Now().Add(time.Hour)
+	db.row = func(dest ...interface{}) error {
+		*dest[0].(*string) = "abc"
+		*dest[1].(*sql.NullInt64) = sql.NullInt64{Int64: 201, Valid: true}
+		*dest[2].(*sql.NullString) = sql.NullString{String: `{"Location":["/orders/1"]}`, Valid: true}
+		*dest[3].(*[]byte) = []byte(`{"id":1}`)
+		*dest[4].(*time.Time) = expires
+		return nil
+	}
+	found, err := store.Get(ctx, "POST /orders", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, 201, found.StatusCode)
+	assert.Equal(t, "/orders/1", found.Header.Get("Location"))
+	assert.Equal(t, `{"id":1}`, string(found.Body))
+	assert.Equal(t, expires, found.ExpiresAt)
+
+	db.row = func(dest ...interface{}) error {
+		*dest[0].(*string) = "abc"
+		*dest[4].(*time.Time) = expires
+		return nil
+	}
+	pending, err := store.Get(ctx, "POST /orders", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, pending.StatusCode)
+}
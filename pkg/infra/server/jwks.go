@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Default JWKS settings
+const (
+	defaultJWKSCacheTTL     = time.Hour
+	defaultJWKSRefreshDelay = time.Minute
+	maxJWKSSize             = 1 << 20
+)
+
+// jwk represents a JSON Web Key
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey represents a parsed verification key
+type publicKey struct {
+	kid string
+	key interface{}
+}
+
+// keySet loads and caches JSON Web Key Sets from a file or URL. Stale sets
+// are refreshed in the background while the cached keys keep being served;
+// unknown key IDs wait for a refresh. Refreshes are shared by concurrent
+// callers and start at most once per refresh delay, so an unavailable key
+// set is not fetched on every request.
+type keySet struct {
+	file         string
+	url          string
+	ttl          time.Duration
+	refreshDelay time.Duration
+	client       *http.Client
+
+	mu          sync.Mutex
+	keys        []publicKey
+	loadedAt    time.Time
+	lastAttempt time.Time
+	lastErr     error
+	// refreshing is closed when the running refresh completes
+	refreshing chan struct{}
+}
+
+// newKeySet creates a key set and loads it once to fail fast on bad input
+func newKeySet(file, url string, ttl, refreshDelay time.Duration, client *http.Client) (*keySet, error) {
+	if ttl <= 0 {
+		ttl = defaultJWKSCacheTTL
+	}
+	if refreshDelay <= 0 {
+		refreshDelay = defaultJWKSRefreshDelay
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &keySet{file: file, url: url, ttl: ttl, refreshDelay: refreshDelay, client: client}
+
+	keys, err := s.load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.lastAttempt = s.loadedAt
+	return s, nil
+}
+
+// key returns the key with the given ID, or the only key of the algorithm's
+// type if kid is empty
+func (s *keySet) key(ctx context.Context, kid, alg string) (interface{}, error) {
+	s.mu.Lock()
+	canRefresh := time.Since(s.lastAttempt) >= s.refreshDelay
+	if key := s.findLocked(kid, alg); key != nil {
+		if canRefresh && time.Since(s.loadedAt) > s.ttl {
+			s.refreshLocked()
+		}
+		s.mu.Unlock()
+		return key, nil
+	}
+	if !canRefresh && s.refreshing == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("no key %q for %s", kid, alg)
+	}
+	done := s.refreshLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key := s.findLocked(kid, alg); key != nil {
+		return key, nil
+	}
+	if s.lastErr != nil {
+		return nil, s.lastErr
+	}
+	return nil, fmt.Errorf("no key %q for %s", kid, alg)
+}
+
+// refreshLocked starts a refresh unless one is running and returns the
+// channel closed on its completion; s.mu must be held
+func (s *keySet) refreshLocked() chan struct{} {
+	if s.refreshing != nil {
+		return s.refreshing
+	}
+	done := make(chan struct{})
+	s.refreshing = done
+	s.lastAttempt = time.Now()
+
+	// The fetch is shared, so it is not bound to any caller's context
+	go func() {
+		keys, err := s.load(context.Background())
+
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+			s.loadedAt = time.Now()
+		}
+		s.lastErr = err
+		s.refreshing = nil
+		s.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// findLocked looks up a cached key; s.mu must be held
+func (s *keySet) findLocked(kid, alg string) interface{} {
+	var match interface{}
+	for _, k := range s.keys {
+		if !keyMatchesAlg(k.key, alg) {
+			continue
+		}
+		if kid != "" && k.kid == kid {
+			return k.key
+		}
+		if kid == "" {
+			if match != nil {
+				return nil
+			}
+			match = k.key
+		}
+	}
+	return match
+}
+
+// load reads and parses the key set
+func (s *keySet) load(ctx context.Context) ([]publicKey, error) {
+	data, err := s.read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	return keys, nil
+}
+
+// read returns the raw key set
+func (s *keySet) read(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", s.url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// parseJWKS parses the RSA and P-256 EC signing keys of a key set
+func parseJWKS(data []byte) ([]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []publicKey
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: invalid modulus: %w", k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil || !e.IsInt64() {
+				return nil, fmt.Errorf("key %q: invalid exponent", k.Kid)
+			}
+			keys = append(keys, publicKey{kid: k.Kid, key: &rsa.PublicKey{N: n, E: int(e.Int64())}})
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, errX := decodeBigInt(k.X)
+			y, errY := decodeBigInt(k.Y)
+			if errX != nil || errY != nil || !elliptic.P256().IsOnCurve(x, y) {
+				return nil, fmt.Errorf("key %q: invalid P-256 point", k.Kid)
+			}
+			keys = append(keys, publicKey{kid: k.Kid, key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}})
+		}
+	}
+	return keys, nil
+}
+
+// keyMatchesAlg reports whether a key can verify an algorithm
+func keyMatchesAlg(key interface{}, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256"
+	case *ecdsa.PublicKey:
+		return alg == "ES256"
+	default:
+		return false
+	}
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	apperrors "order-system/pkg/infra/errors"
+	"order-system/pkg/platform/logger"
+)
+
+// claimsKey is the context key of verified JWT claims
+type claimsKey struct{}
+
+// JWTConfig represents the settings of JWT verification. HS256 is accepted
+// when Secret is set, RS256 and ES256 when a JWKS file or URL is set.
+type JWTConfig struct {
+	Secret []byte
+
+	JWKSFile string
+	JWKSURL  string
+	// JWKSCacheTTL is how long a fetched key set is used before it is
+	// refreshed in the background; defaults to 1h
+	JWKSCacheTTL time.Duration
+	// JWKSRefreshDelay is the minimum interval between fetches of the key
+	// set, also while it is unavailable; defaults to 1m
+	JWKSRefreshDelay time.Duration
+	// HTTPClient fetches JWKSURL; defaults to a client with a 10s timeout
+	HTTPClient *http.Client
+
+	// Issuer and Audience are required to match when set
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+
+	// Logger records why tokens were rejected, which is not disclosed to
+	// clients; optional
+	Logger logger.Logger
+}
+
+// Audience represents the aud claim, which may be a string or an array
+type Audience []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// Claims represents the verified claims of a token
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  Audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	IssuedAt  *float64 `json:"iat"`
+	Scope     string   `json:"scope"`
+	Roles     []string `json:"roles"`
+
+	// Raw holds all claims, including custom ones
+	Raw map[string]interface{} `json:"-"`
+}
+
+// HasRole reports whether the claims grant a role
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the space-separated scope claim grants a scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimsFromContext returns the claims stored by the JWT middleware
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// JWTVerifier verifies signed JWTs
+type JWTVerifier struct {
+	cfg  JWTConfig
+	keys *keySet
+}
+
+// NewJWTVerifier creates a verifier. A configured key set is loaded
+// immediately so that invalid settings fail at startup.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{cfg: cfg}
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		keys, err := newKeySet(cfg.JWKSFile, cfg.JWKSURL, cfg.JWKSCacheTTL, cfg.JWKSRefreshDelay, cfg.HTTPClient)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	}
+	if len(cfg.Secret) == 0 && v.keys == nil {
+		return nil, fmt.Errorf("jwt: a secret or a JWKS file or URL is required")
+	}
+	return v, nil
+}
+
+// Verify checks the signature and registered claims of a token
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	if err := v.verifySignature(ctx, header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	if err := decodeSegment(parts[1], &claims.Raw); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature checks the signature of signed with the algorithm's key
+func (v *JWTVerifier) verifySignature(ctx context.Context, alg, kid, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "HS256":
+		if len(v.cfg.Secret) == 0 {
+			return fmt.Errorf("unsupported algorithm %s", alg)
+		}
+		mac := hmac.New(sha256.New, v.cfg.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case "RS256", "ES256":
+		if v.keys == nil {
+			return fmt.Errorf("unsupported algorithm %s", alg)
+		}
+		key, err := v.keys.key(ctx, kid, alg)
+		if err != nil {
+			return err
+		}
+		if pub, ok := key.(*rsa.PublicKey); ok {
+			if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+				return fmt.Errorf("invalid signature")
+			}
+			return nil
+		}
+		pub := key.(*ecdsa.PublicKey)
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+// validateClaims checks exp, nbf, iss and aud
+func (v *JWTVerifier) validateClaims(c *Claims) error {
+	now := time.Now()
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(v.cfg.Leeway)) {
+		return fmt.Errorf("token has expired")
+	}
+	if c.NotBefore != nil && now.Add(v.cfg.Leeway).Before(unixTime(*c.NotBefore)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	if v.cfg.Issuer != "" && c.Issuer != v.cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if v.cfg.Audience != "" {
+		found := false
+		for _, aud := range c.Audience {
+			if aud == v.cfg.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("token is not intended for %q", v.cfg.Audience)
+		}
+	}
+	return nil
+}
+
+// Middleware verifies the bearer token of every request, storing the claims
+// in the request context. Missing or invalid tokens fail with 401; why a
+// token was rejected is only logged.
+func (v *JWTVerifier) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				WriteError(w, r, apperrors.New(CodeUnauthorized, "bearer token is required"))
+				return
+			}
+
+			claims, err := v.Verify(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				if v.cfg.Logger != nil {
+					v.cfg.Logger.Warn(r.Context(), "token rejected",
+						logger.Field{Key: "error", Value: err.Error()},
+						logger.Field{Key: "request_id", Value: RequestIDFromContext(r.Context())},
+					)
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				WriteError(w, r, apperrors.New(CodeUnauthorized, "invalid token"))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsKey{}, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireRoles rejects requests whose claims lack any of roles with 403,
+// and unauthenticated requests with 401
+func RequireRoles(roles ...string) Middleware {
+	return requireClaims(func(c *Claims) (string, bool) {
+		for _, role := range roles {
+			if !c.HasRole(role) {
+				return "role " + role, false
+			}
+		}
+		return "", true
+	})
+}
+
+// RequireScopes rejects requests whose claims lack any of scopes with 403,
+// and unauthenticated requests with 401
+func RequireScopes(scopes ...string) Middleware {
+	return requireClaims(func(c *Claims) (string, bool) {
+		for _, scope := range scopes {
+			if !c.HasScope(scope) {
+				return "scope " + scope, false
+			}
+		}
+		return "", true
+	})
+}
+
+// requireClaims rejects requests whose claims fail check
+func requireClaims(check func(c *Claims) (string, bool)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				WriteError(w, r, apperrors.New(CodeUnauthorized, "authentication is required"))
+				return
+			}
+			if missing, ok := check(claims); !ok {
+				WriteError(w, r, apperrors.New(CodeForbidden, missing+" is required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a NumericDate to a time
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,310 @@
+package server_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jwtSecret = []byte("test-secret")
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signToken builds a token signed with key: a []byte secret, an RSA or an EC key
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + b64(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "user-1",
+		"iss":   "https://auth.example.com",
+		"aud":   []string{"orders", "payments"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "orders:read orders:write",
+		"roles": []string{"customer"},
+		"tier":  "gold",
+	}
+}
+
+func jwksJSON(rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": b64(rsaKey.N.Bytes()),
+				"e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec-1", "crv": "P-256",
+				"x": b64(ecKey.X.FillBytes(make([]byte, 32))),
+				"y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	})
+	return data
+}
+
+func newTestKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return rsaKey, ecKey
+}
+
+func authRequest(h http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func claimsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := server.ClaimsFromContext(r.Context())
+		w.Write([]byte(claims.Subject + " " + claims.Raw["tier"].(string)))
+	})
+}
+
+func TestJWTHS256(t *testing.T) {
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{
+		Secret:   jwtSecret,
+		Issuer:   "https://auth.example.com",
+		Audience: "orders",
+	})
+	require.NoError(t, err)
+	h := verifier.Middleware()(claimsHandler())
+
+	rec := authRequest(h, signToken(t, "HS256", "", jwtSecret, validClaims()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-1 gold", rec.Body.String())
+
+	rec = authRequest(h, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, server.ContentTypeProblem, rec.Header().Get("Content-Type"))
+}
+
+func TestJWTRejectsInvalidTokens(t *testing.T) {
+	log := &recordingLogger{}
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{
+		Secret:   jwtSecret,
+		Issuer:   "https://auth.example.com",
+		Audience: "orders",
+		Logger:   log,
+	})
+	require.NoError(t, err)
+	h := verifier.Middleware()(claimsHandler())
+
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	unsigned := func(claims map[string]interface{}) string {
+		token := signToken(t, "HS256", "", jwtSecret, claims)
+		header := b64([]byte(`{"alg":"none"}`))
+		return header + token[len(b64([]byte(`{"alg":"HS256","kid":"","typ":"JWT"}`))):]
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		message string
+	}{
+		{"expired", signToken(t, "HS256", "", jwtSecret, with("exp", time.Now().Add(-time.Minute).Unix())), "expired"},
+		{"no expiry", signToken(t, "HS256", "", jwtSecret, with("exp", nil)), "no expiry"},
+		{"not yet valid", signToken(t, "HS256", "", jwtSecret, with("nbf", time.Now().Add(time.Hour).Unix())), "not valid yet"},
+		{"wrong issuer", signToken(t, "HS256", "", jwtSecret, with("iss", "https://evil.example.com")), "issuer"},
+		{"wrong audience", signToken(t, "HS256", "", jwtSecret, with("aud", "billing")), "not intended"},
+		{"wrong secret", signToken(t, "HS256", "", []byte("other"), validClaims()), "invalid signature"},
+		{"alg none", unsigned(validClaims()), "unsupported algorithm"},
+		{"malformed", "not-a-token", "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := authRequest(h, tt.token)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "invalid_token")
+			assert.Contains(t, rec.Body.String(), `"detail":"invalid token"`)
+			assert.NotContains(t, rec.Body.String(), tt.message)
+
+			// The reason is logged instead
+			entries := log.Entries()
+			require.NotEmpty(t, entries)
+			last := entries[len(entries)-1]
+			assert.Equal(t, "token rejected", last.Message)
+			assert.Contains(t, last.Fields[0].Value, tt.message)
+		})
+	}
+}
+
+func TestJWTJWKSFile(t *testing.T) {
+	rsaKey, ecKey := newTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwksJSON(rsaKey, ecKey), 0644))
+
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{JWKSFile: path})
+	require.NoError(t, err)
+	h := verifier.Middleware()(claimsHandler())
+
+	assert.Equal(t, http.StatusOK, authRequest(h, signToken(t, "RS256", "rsa-1", rsaKey, validClaims())).Code)
+	assert.Equal(t, http.StatusOK, authRequest(h, signToken(t, "ES256", "ec-1", ecKey, validClaims())).Code)
+
+	// HS256 is not accepted without a secret, even with the key set's key material
+	rec := authRequest(h, signToken(t, "HS256", "", jwtSecret, validClaims()))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	otherKey, _ := newTestKeys(t)
+	rec = authRequest(h, signToken(t, "RS256", "rsa-1", otherKey, validClaims()))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestJWTJWKSURLCaching(t *testing.T) {
+	rsaKey, ecKey := newTestKeys(t)
+	var fetches int32
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(jwksJSON(rsaKey, ecKey))
+	}))
+	defer jwks.Close()
+
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{JWKSURL: jwks.URL})
+	require.NoError(t, err)
+	h := verifier.Middleware()(claimsHandler())
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, authRequest(h, signToken(t, "ES256", "ec-1", ecKey, validClaims())).Code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// Unknown key IDs refresh the key set at most once per minute
+	authRequest(h, signToken(t, "RS256", "rsa-2", rsaKey, validClaims()))
+	authRequest(h, signToken(t, "RS256", "rsa-2", rsaKey, validClaims()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestJWTJWKSOutage(t *testing.T) {
+	rsaKey, ecKey := newTestKeys(t)
+	var fetches int32
+	release := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			w.Write(jwksJSON(rsaKey, ecKey))
+			return
+		}
+		// The key set hangs until the test ends
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer jwks.Close()
+	defer close(release)
+
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{
+		JWKSURL:          jwks.URL,
+		JWKSCacheTTL:     time.Millisecond,
+		JWKSRefreshDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+	h := verifier.Middleware()(claimsHandler())
+	time.Sleep(5 * time.Millisecond)
+
+	// Stale keys keep being served while a single refresh is pending
+	token := signToken(t, "ES256", "ec-1", ecKey, validClaims())
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, authRequest(h, token).Code)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestJWTConfigErrors(t *testing.T) {
+	_, err := server.NewJWTVerifier(server.JWTConfig{})
+	assert.Error(t, err)
+
+	_, err = server.NewJWTVerifier(server.JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+	assert.Error(t, err)
+}
+
+func TestRequireRolesAndScopes(t *testing.T) {
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{Secret: jwtSecret})
+	require.NoError(t, err)
+
+	router := server.NewRouter()
+	api := router.Group("/api", verifier.Middleware())
+	api.Group("", server.RequireScopes("orders:read")).Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+	api.Group("", server.RequireRoles("admin")).Get("/admin", func(w http.ResponseWriter, r *http.Request) {})
+	router.Group("", server.RequireRoles("admin")).Get("/open", func(w http.ResponseWriter, r *http.Request) {})
+
+	token := signToken(t, "HS256", "", jwtSecret, validClaims())
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("/api/orders").Code)
+
+	rec := request("/api/admin")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "role admin is required")
+
+	// Requirements without authentication fail with 401
+	assert.Equal(t, http.StatusUnauthorized, request("/open").Code)
+}
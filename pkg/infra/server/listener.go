@@ -0,0 +1,31 @@
+package server
+
+import (
+	"fmt"
+	"net"
+)
+
+// tcpListener binds a TCP address
+type tcpListener struct {
+	addr string
+}
+
+// NewTCPListener creates a listener bound to a TCP address
+func NewTCPListener(addr string) Listener {
+	return &tcpListener{addr: addr}
+}
+
+// NewRandomListener creates a listener bound to a random loopback port,
+// for use in tests. Server.Addr reports the chosen port once started.
+func NewRandomListener() Listener {
+	return &tcpListener{addr: "127.0.0.1:0"}
+}
+
+// Listen implements Listener.Listen
+func (l *tcpListener) Listen() (net.Listener, error) {
+	ln, err := net.Listen("tcp", l.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", l.addr, err)
+	}
+	return ln, nil
+}
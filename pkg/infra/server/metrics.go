@@ -0,0 +1,19 @@
+package server
+
+import (
+	"net/http"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/metrics"
+)
+
+// AttachMetrics registers GET config.Metrics.Endpoint on router, serving
+// the metrics of collector in the Prometheus text format. Nothing is
+// registered when metrics are disabled. The route is not documented in the
+// OpenAPI document.
+func AttachMetrics(router *Router, cfg *config.Config, collector metrics.Collector) {
+	if !cfg.Metrics.Enabled || collector == nil {
+		return
+	}
+	router.Handle(http.MethodGet, cfg.Metrics.Endpoint, metrics.Handler(collector)).Doc(RouteDoc{Hidden: true})
+}
@@ -0,0 +1,43 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/server"
+	"order-system/pkg/platform/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachMetrics(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Endpoint = "/metrics"
+	collector := newMetricsCollector(t)
+	require.NoError(t, collector.Register("orders_total", metrics.Counter, "Total number of orders"))
+	collector.IncrementCounter("orders_total", 2, nil)
+
+	router := server.NewRouter()
+	server.AttachMetrics(router, cfg, collector)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, metrics.ContentTypeText, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "orders_total 2\n")
+
+	doc := router.OpenAPI(server.OpenAPIInfo{Title: "Orders", Version: "1"})
+	assert.NotContains(t, doc.Paths, "/metrics")
+
+	// Disabled metrics are not served
+	cfg.Metrics.Enabled = false
+	router = server.NewRouter()
+	server.AttachMetrics(router, cfg, collector)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
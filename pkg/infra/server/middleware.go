@@ -0,0 +1,251 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+	httpclient "order-system/pkg/infra/http"
+	"order-system/pkg/platform/logger"
+)
+
+// HeaderRequestID is the header carrying the request ID
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds accepted incoming request IDs
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// StandardMiddleware returns the default middleware stack: request IDs,
+// problem responses honoring config.HTTP.Debug, access logging, panic
+// recovery, security headers, CORS, config.HTTP.RequestTimeout and
+// config.HTTP.MaxRequestSize
+func StandardMiddleware(cfg *config.Config, log logger.Logger) []Middleware {
+	return []Middleware{
+		RequestID(),
+		Problems(NewProblemWriter(DefaultStatuses, cfg.HTTP.Debug)),
+		AccessLog(log),
+		Recover(log),
+		SecurityHeaders(cfg.SecurityHeaders),
+		CORS(cfg.CORS),
+		Timeout(cfg.HTTP.RequestTimeout),
+		BodyLimit(cfg.HTTP.MaxRequestSize),
+	}
+}
+
+// RequestID reuses a valid incoming X-Request-ID or generates one, stores it
+// in the request context and echoes it in the response. The ID is also used
+// as the trace ID of log entries when no trace ID is set.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(HeaderRequestID, id)
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			if _, ok := ctx.Value("trace_id").(string); !ok {
+				ctx = context.WithValue(ctx, "trace_id", id)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID stored by RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// PropagateRequestID returns an HTTP client interceptor that forwards the
+// request ID of the context to downstream services
+func PropagateRequestID() httpclient.Interceptor {
+	return func(req *http.Request, info *httpclient.AttemptInfo, next httpclient.Invoker) (*httpclient.Response, error) {
+		if id := RequestIDFromContext(req.Context()); id != "" && req.Header.Get(HeaderRequestID) == "" {
+			req.Header.Set(HeaderRequestID, id)
+		}
+		return next(req)
+	}
+}
+
+// validRequestID reports whether an incoming request ID is safe to reuse
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Recover converts panics into 500 responses carrying an errors.Error and
+// logs them with their stack
+func Recover(log logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := wrapResponseWriter(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				err := apperrors.New(CodeInternal, "internal server error").
+					WithMetadata("panic", fmt.Sprint(v))
+				if cause, ok := v.(error); ok {
+					err.Err = cause
+				}
+				log.Error(r.Context(), "panic recovered", err,
+					logger.Field{Key: "method", Value: r.Method},
+					logger.Field{Key: "route", Value: RouteTemplate(r)},
+					logger.Field{Key: "stack", Value: err.Stack},
+				)
+
+				if !rw.wroteHeader {
+					WriteError(rw, r, err)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// AccessLog logs every request with its route template, status, size and
+// duration. Server errors are logged at error level.
+func AccessLog(log logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := wrapResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			fields := []logger.Field{
+				{Key: "method", Value: r.Method},
+				{Key: "route", Value: RouteTemplate(r)},
+				{Key: "path", Value: r.URL.Path},
+				{Key: "status", Value: rw.Status()},
+				{Key: "bytes", Value: rw.bytes},
+				{Key: "duration_ms", Value: time.Since(start).Milliseconds()},
+				{Key: "request_id", Value: RequestIDFromContext(r.Context())},
+				{Key: "remote_addr", Value: r.RemoteAddr},
+			}
+			if rw.Status() >= http.StatusInternalServerError {
+				log.Error(r.Context(), "request completed", nil, fields...)
+				return
+			}
+			log.Info(r.Context(), "request completed", fields...)
+		})
+	}
+}
+
+// Timeout bounds the request context by d. Handlers must honor the context;
+// if one returns after the deadline without writing a response, a 503 is
+// sent. A non-positive d disables the timeout. Requests accepting an event
+// stream are long-lived and exempt.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.Header.Get("Accept"), ContentTypeEventStream) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			rw := wrapResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if !rw.wroteHeader && ctx.Err() == context.DeadlineExceeded {
+				WriteError(rw, r, apperrors.New(CodeTimeout, "request timed out"))
+			}
+		})
+	}
+}
+
+// responseWriter records the status and size of a response
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// wrapResponseWriter wraps w, reusing it if it is already wrapped
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w}
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the response status, defaulting to 200
+func (w *responseWriter) Status() int {
+	if !w.wroteHeader {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,188 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+	httpclient "order-system/pkg/infra/http"
+	"order-system/pkg/infra/server"
+	"order-system/pkg/platform/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger implements logger.Logger by recording entries
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logger.Entry
+}
+
+func (l *recordingLogger) record(level logger.Level, msg string, err error, fields []logger.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logger.Entry{Level: level, Message: msg, Error: err, Fields: fields})
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Debug, msg, nil, fields)
+}
+
+func (l *recordingLogger) Info(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Info, msg, nil, fields)
+}
+
+func (l *recordingLogger) Warn(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Warn, msg, nil, fields)
+}
+
+func (l *recordingLogger) Error(ctx context.Context, msg string, err error, fields ...logger.Field) {
+	l.record(logger.Error, msg, err, fields)
+}
+
+func (l *recordingLogger) WithComponent(component string) logger.Logger { return l }
+
+func (l *recordingLogger) WithFields(fields ...logger.Field) logger.Logger { return l }
+
+func (l *recordingLogger) Entries() []logger.Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logger.Entry(nil), l.entries...)
+}
+
+func fieldValue(entry logger.Entry, key string) interface{} {
+	for _, f := range entry.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := server.RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = server.RequestIDFromContext(r.Context())
+	}))
+
+	rec := serve(h, http.MethodGet, "/")
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, rec.Header().Get(server.HeaderRequestID))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(server.HeaderRequestID, "upstream-id")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "upstream-id", seen)
+	assert.Equal(t, "upstream-id", rec.Header().Get(server.HeaderRequestID))
+
+	req.Header.Set(server.HeaderRequestID, strings.Repeat("x", 200))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, seen, 32)
+}
+
+func TestPropagateRequestID(t *testing.T) {
+	var forwarded string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(server.HeaderRequestID)
+	}))
+	defer downstream.Close()
+
+	cfg := &config.Config{}
+	cfg.HTTP.RequestTimeout = 5 * time.Second
+	cfg.HTTP.MaxRequestSize = 1024
+	client := httpclient.NewClient(cfg, downstream.URL, httpclient.WithInterceptors(server.PropagateRequestID()))
+
+	h := server.RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := client.Get(r.Context(), "/", nil)
+		require.NoError(t, err)
+	}))
+	rec := serve(h, http.MethodGet, "/")
+
+	assert.NotEmpty(t, forwarded)
+	assert.Equal(t, rec.Header().Get(server.HeaderRequestID), forwarded)
+}
+
+func TestRecover(t *testing.T) {
+	log := &recordingLogger{}
+	router := server.NewRouter()
+	router.Use(server.RequestID(), server.Recover(log))
+	router.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("boom"))
+	})
+
+	rec := serve(router, http.MethodGet, "/orders/1")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "INTERNAL_ERROR")
+	assert.NotContains(t, rec.Body.String(), "boom")
+
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logger.Error, entries[0].Level)
+	assert.Equal(t, "/orders/{id}", fieldValue(entries[0], "route"))
+	assert.NotEmpty(t, fieldValue(entries[0], "stack"))
+
+	var appErr *apperrors.Error
+	require.True(t, errors.As(entries[0].Error, &appErr))
+	assert.Equal(t, "INTERNAL_ERROR", appErr.Code)
+	assert.Equal(t, "boom", appErr.Metadata["panic"])
+}
+
+func TestAccessLog(t *testing.T) {
+	log := &recordingLogger{}
+	router := server.NewRouter()
+	router.Use(server.StandardMiddleware(&config.Config{}, log)...)
+	router.Post("/orders/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("accepted"))
+	})
+	router.Get("/broken", func(w http.ResponseWriter, r *http.Request) {
+		panic("broken")
+	})
+
+	rec := serve(router, http.MethodPost, "/orders/9/cancel")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, logger.Info, entry.Level)
+	assert.Equal(t, http.MethodPost, fieldValue(entry, "method"))
+	assert.Equal(t, "/orders/{id}/cancel", fieldValue(entry, "route"))
+	assert.Equal(t, "/orders/9/cancel", fieldValue(entry, "path"))
+	assert.Equal(t, http.StatusAccepted, fieldValue(entry, "status"))
+	assert.Equal(t, int64(8), fieldValue(entry, "bytes"))
+	assert.Equal(t, rec.Header().Get(server.HeaderRequestID), fieldValue(entry, "request_id"))
+
+	serve(router, http.MethodGet, "/broken")
+	entries = log.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, logger.Error, entries[2].Level)
+	assert.Equal(t, http.StatusInternalServerError, fieldValue(entries[2], "status"))
+}
+
+func TestTimeout(t *testing.T) {
+	h := server.Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	rec := serve(h, http.MethodGet, "/")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "TIMEOUT")
+
+	fast := server.Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		w.Write([]byte("ok"))
+	}))
+	rec = serve(fast, http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+}
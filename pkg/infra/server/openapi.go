@@ -0,0 +1,546 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "order-system/pkg/infra/errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion is the OpenAPI version of generated documents
+const OpenAPIVersion = "3.0.3"
+
+// RouteDoc documents a route in the OpenAPI document. Routes without one
+// are listed with their path parameters and a bodiless 200 response.
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	OperationID string
+	Deprecated  bool
+	// Hidden leaves the route out of the document
+	Hidden bool
+
+	// Query is a struct whose fields document the query parameters
+	Query interface{}
+	// Request is a value of the JSON request body type. Binding failures
+	// (400, 413 and 422) are documented with it.
+	Request interface{}
+	// Responses maps statuses to values of the JSON response body types; a
+	// nil value documents a response without body
+	Responses map[int]interface{}
+	// Errors lists further statuses answered with problem details
+	Errors []int
+}
+
+// OpenAPIDocument represents an OpenAPI 3 document
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi" yaml:"openapi"`
+	Info       OpenAPIInfo                `json:"info" yaml:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths" yaml:"paths"`
+	Components OpenAPIComponents          `json:"components" yaml:"components"`
+}
+
+// OpenAPIInfo represents the metadata of the API
+type OpenAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// OpenAPIPathItem maps lowercase methods to the operations of a path
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation represents an operation on a path
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                      `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+// OpenAPIParameter represents a path or query parameter
+type OpenAPIParameter struct {
+	Name     string  `json:"name" yaml:"name"`
+	In       string  `json:"in" yaml:"in"`
+	Required bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *Schema `json:"schema" yaml:"schema"`
+}
+
+// OpenAPIRequestBody represents the body of a request
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+// OpenAPIResponse represents a response of an operation
+type OpenAPIResponse struct {
+	Description string                      `json:"description" yaml:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// OpenAPIMediaType represents the schema of a body
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema" yaml:"schema"`
+}
+
+// OpenAPIComponents holds the named schemas referenced by the document
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas" yaml:"schemas"`
+}
+
+// Schema represents a JSON schema as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Default              interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+}
+
+// JSON encodes the document as indented JSON
+func (d *OpenAPIDocument) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// YAML encodes the document as YAML
+func (d *OpenAPIDocument) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// OpenAPI generates the OpenAPI document of the registered routes. Schemas
+// are reflected from the types in RouteDoc, with properties named by their
+// `json` tags and constraints taken from their `validate` tags; named
+// struct types become shared components. Errors are documented as
+// problem details.
+func (r *Router) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	b := newOpenAPIBuilder()
+	b.problemSchema()
+
+	doc := &OpenAPIDocument{
+		OpenAPI:    OpenAPIVersion,
+		Info:       info,
+		Paths:      make(map[string]OpenAPIPathItem),
+		Components: OpenAPIComponents{Schemas: b.schemas},
+	}
+	for _, rt := range r.routes {
+		if rt.doc != nil && rt.doc.Hidden {
+			continue
+		}
+		path, params := openAPIPath(rt.template)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(OpenAPIPathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = b.operation(rt, params)
+	}
+	return doc
+}
+
+// ServeOpenAPI registers GET /openapi.json and /openapi.yaml serving the
+// document of the router. It is generated on the first request, so routes
+// registered afterwards are included.
+func (r *Router) ServeOpenAPI(info OpenAPIInfo) {
+	var once sync.Once
+	var jsonDoc, yamlDoc []byte
+	var err error
+	generate := func() {
+		doc := r.OpenAPI(info)
+		if jsonDoc, err = doc.JSON(); err == nil {
+			yamlDoc, err = doc.YAML()
+		}
+	}
+
+	serve := func(contentType string, body *[]byte) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			once.Do(generate)
+			if err != nil {
+				WriteError(w, req, apperrors.Wrap(err, CodeInternal, "failed to generate OpenAPI document"))
+				return
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Write(*body)
+		}
+	}
+	r.Get("/openapi.json", serve("application/json", &jsonDoc)).Doc(RouteDoc{Hidden: true})
+	r.Get("/openapi.yaml", serve("application/yaml", &yamlDoc)).Doc(RouteDoc{Hidden: true})
+}
+
+// openAPIBuilder collects the schemas of a document
+type openAPIBuilder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// newOpenAPIBuilder creates a builder without schemas
+func newOpenAPIBuilder() *openAPIBuilder {
+	return &openAPIBuilder{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// problemSchema registers the schema of problem details bodies
+func (b *openAPIBuilder) problemSchema() {
+	b.schemaOf(reflect.TypeOf(Problem{}))
+	problem := b.schemas["Problem"]
+	problem.Description = "RFC 7807 problem details. Detail is omitted for server errors; " +
+		"cause, stack and metadata are only present in debug mode."
+	problem.Required = []string{"type", "title", "status", "code"}
+	problem.Properties["type"].Default = "about:blank"
+	problem.Properties["code"].Description = "Application error code, e.g. " + CodeValidation
+	problem.Properties["errors"].Description = "Field errors of validation failures"
+}
+
+// operation builds the operation of a route
+func (b *openAPIBuilder) operation(rt *Route, params []OpenAPIParameter) *OpenAPIOperation {
+	var doc RouteDoc
+	if rt.doc != nil {
+		doc = *rt.doc
+	}
+
+	op := &OpenAPIOperation{
+		OperationID: doc.OperationID,
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		Tags:        doc.Tags,
+		Deprecated:  doc.Deprecated,
+		Parameters:  params,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+	if doc.Query != nil {
+		op.Parameters = append(op.Parameters, b.queryParameters(reflect.TypeOf(doc.Query))...)
+	}
+
+	errorStatuses := doc.Errors
+	if doc.Request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  jsonContent(b.schemaOf(reflect.TypeOf(doc.Request))),
+		}
+		errorStatuses = append([]int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}, errorStatuses...)
+	}
+
+	responses := doc.Responses
+	if len(responses) == 0 {
+		responses = map[int]interface{}{http.StatusOK: nil}
+	}
+	for status, body := range responses {
+		resp := &OpenAPIResponse{Description: http.StatusText(status)}
+		if body != nil {
+			resp.Content = jsonContent(b.schemaOf(reflect.TypeOf(body)))
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	for _, status := range errorStatuses {
+		op.Responses[strconv.Itoa(status)] = problemResponse(http.StatusText(status))
+	}
+	op.Responses["default"] = problemResponse("Error")
+	return op
+}
+
+// queryParameters documents the fields of a struct as query parameters
+func (b *openAPIBuilder) queryParameters(t reflect.Type) []OpenAPIParameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("server: RouteDoc.Query must be a struct, got %s", t))
+	}
+
+	var params []OpenAPIParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		schema := b.schemaOf(field.Type)
+		required := applyRules(schema, field.Type, name, field.Tag.Get("validate"))
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: required,
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+// schemaOf returns the schema of a type, registering named structs as
+// components and referencing them
+func (b *openAPIBuilder) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if name, ok := b.names[t]; ok {
+			return &Schema{Ref: "#/components/schemas/" + name}
+		}
+		name := b.componentName(t)
+		b.names[t] = name
+		// Register before building so that recursive types resolve
+		schema := &Schema{}
+		b.schemas[name] = schema
+		*schema = *b.structSchema(t)
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema builds the object schema of a struct
+func (b *openAPIBuilder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		// Fields of embedded structs are promoted as encoding/json does
+		embedded := field.Type
+		for embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" && embedded.Kind() == reflect.Struct {
+			inner := b.structSchema(embedded)
+			for k, v := range inner.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, inner.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		property := b.schemaOf(field.Type)
+		if applyRules(property, field.Type, name, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// componentNameChars matches characters not allowed in component names
+var componentNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentName returns a unique component name for a named type
+func (b *openAPIBuilder) componentName(t reflect.Type) string {
+	name := componentNameChars.ReplaceAllString(t.Name(), "_")
+	if _, taken := b.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name = componentNameChars.ReplaceAllString(pkg, "_") + "." + name
+	for i := 2; ; i++ {
+		if _, taken := b.schemas[name]; !taken {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+}
+
+// applyRules adds the constraints of a validate tag to a schema and reports
+// whether the value is required. Referenced schemas cannot carry
+// constraints in OpenAPI 3.0 and only take the required rule.
+func applyRules(schema *Schema, t reflect.Type, name, tag string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	required := false
+	for _, rule := range splitRules(tag) {
+		rule, arg, _ := strings.Cut(rule, "=")
+		if rule == "required" {
+			required = true
+			continue
+		}
+		if schema.Ref != "" {
+			continue
+		}
+
+		switch rule {
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("server: invalid %s rule on %s: %q", rule, name, arg))
+			}
+			setLimit(schema, t, rule == "min", limit)
+		case "enum":
+			for _, value := range strings.Split(arg, "|") {
+				schema.Enum = append(schema.Enum, enumValue(t, value))
+			}
+		case "regex":
+			schema.Pattern = arg
+		default:
+			panic(fmt.Sprintf("server: unknown validation rule %q on %s", rule, name))
+		}
+	}
+	return required
+}
+
+// setLimit sets the bound of a min or max rule matching the kind of t
+func setLimit(schema *Schema, t reflect.Type, min bool, limit float64) {
+	size := int(limit)
+	switch t.Kind() {
+	case reflect.String:
+		if min {
+			schema.MinLength = &size
+		} else {
+			schema.MaxLength = &size
+		}
+	case reflect.Slice, reflect.Array:
+		if min {
+			schema.MinItems = &size
+		} else {
+			schema.MaxItems = &size
+		}
+	case reflect.Map:
+		// Property counts are not part of the generated schemas
+	default:
+		if min {
+			schema.Minimum = &limit
+		} else {
+			schema.Maximum = &limit
+		}
+	}
+}
+
+// enumValue converts an enum rule value to the type of the field
+func enumValue(t reflect.Type, value string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// openAPIPath converts a route template to an OpenAPI path and its
+// parameters; catch-all parameters lose their ... suffix
+func openAPIPath(template string) (string, []OpenAPIParameter) {
+	segments := splitPath(template)
+	var params []OpenAPIParameter
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+		segments[i] = "{" + name + "}"
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return "/" + strings.Join(segments, "/"), params
+}
+
+// jsonContent returns the content of a JSON body
+func jsonContent(schema *Schema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// problemResponse returns a response with a problem details body
+func problemResponse(description string) *OpenAPIResponse {
+	return &OpenAPIResponse{
+		Description: description,
+		Content:     map[string]OpenAPIMediaType{ContentTypeProblem: {Schema: &Schema{Ref: "#/components/schemas/Problem"}}},
+	}
+}
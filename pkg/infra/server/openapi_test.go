@@ -0,0 +1,192 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/server"
+	"order-system/pkg/infra/server/servertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type CreateOrderRequest struct {
+	CustomerID string      `json:"customerId" validate:"required,max=64"`
+	Items      []orderItem `json:"items" validate:"required,min=1"`
+	Priority   int         `json:"priority,omitempty" validate:"enum=1|2|3"`
+	Note       *string     `json:"note,omitempty"`
+	Internal   string      `json:"-"`
+}
+
+type Audit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Order struct {
+	ID     string            `json:"id"`
+	Status string            `json:"status" validate:"enum=pending|paid|shipped"`
+	Items  []orderItem       `json:"items"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Parent *Order            `json:"parent,omitempty"`
+	Audit
+}
+
+type ListOrdersQuery struct {
+	Status string `json:"status" validate:"enum=pending|paid|shipped"`
+	Limit  int    `json:"limit" validate:"min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
+
+func newDocumentedRouter() *server.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router := server.NewRouter()
+	router.ServeOpenAPI(server.OpenAPIInfo{Title: "Orders", Version: "1.0.0"})
+
+	api := router.Group("/v1")
+	api.Post("/orders", ok).Doc(server.RouteDoc{
+		OperationID: "createOrder",
+		Summary:     "Create an order",
+		Tags:        []string{"orders"},
+		Request:     CreateOrderRequest{},
+		Responses:   map[int]interface{}{http.StatusCreated: Order{}},
+		Errors:      []int{http.StatusConflict},
+	})
+	api.Get("/orders", ok).Doc(server.RouteDoc{
+		OperationID: "listOrders",
+		Tags:        []string{"orders"},
+		Query:       ListOrdersQuery{},
+		Responses:   map[int]interface{}{http.StatusOK: []Order{}},
+	})
+	api.Get("/orders/{id}", ok).Doc(server.RouteDoc{
+		OperationID: "getOrder",
+		Tags:        []string{"orders"},
+		Responses:   map[int]interface{}{http.StatusOK: &Order{}},
+		Errors:      []int{http.StatusNotFound},
+	})
+	api.Delete("/orders/{id}", ok)
+	api.Get("/files/{path...}", ok)
+	api.Get("/internal", ok).Doc(server.RouteDoc{Hidden: true})
+	return router
+}
+
+func TestOpenAPI(t *testing.T) {
+	doc := newDocumentedRouter().OpenAPI(server.OpenAPIInfo{Title: "Orders", Version: "1.0.0"})
+
+	assert.Equal(t, server.OpenAPIVersion, doc.OpenAPI)
+	assert.ElementsMatch(t, []string{"/v1/orders", "/v1/orders/{id}", "/v1/files/{path}"}, keys(doc.Paths))
+
+	create := doc.Paths["/v1/orders"]["post"]
+	require.NotNil(t, create)
+	assert.Equal(t, "createOrder", create.OperationID)
+	assert.Equal(t, "#/components/schemas/CreateOrderRequest", create.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/Order", create.Responses["201"].Content["application/json"].Schema.Ref)
+	for _, status := range []string{"400", "409", "413", "422", "default"} {
+		require.Contains(t, create.Responses, status)
+		assert.Equal(t, "#/components/schemas/Problem", create.Responses[status].Content[server.ContentTypeProblem].Schema.Ref, status)
+	}
+
+	get := doc.Paths["/v1/orders/{id}"]["get"]
+	assert.Equal(t, []server.OpenAPIParameter{{Name: "id", In: "path", Required: true, Schema: &server.Schema{Type: "string"}}}, get.Parameters)
+	assert.Contains(t, get.Responses, "404")
+	assert.Nil(t, get.RequestBody)
+
+	// Undocumented routes are listed with a bodiless response
+	remove := doc.Paths["/v1/orders/{id}"]["delete"]
+	assert.Equal(t, "OK", remove.Responses["200"].Description)
+	assert.Nil(t, remove.Responses["200"].Content)
+	assert.Equal(t, "path", doc.Paths["/v1/files/{path}"]["get"].Parameters[0].Name)
+
+	list := doc.Paths["/v1/orders"]["get"]
+	require.Len(t, list.Parameters, 3)
+	limit := list.Parameters[1]
+	assert.Equal(t, "limit", limit.Name)
+	assert.Equal(t, "query", limit.In)
+	assert.False(t, limit.Required)
+	assert.Equal(t, 1.0, *limit.Schema.Minimum)
+	assert.Equal(t, 100.0, *limit.Schema.Maximum)
+	assert.Equal(t, "array", list.Responses["200"].Content["application/json"].Schema.Type)
+
+	schemas := doc.Components.Schemas
+	request := schemas["CreateOrderRequest"]
+	assert.Equal(t, []string{"customerId", "items"}, request.Required)
+	assert.ElementsMatch(t, []string{"customerId", "items", "priority", "note"}, keys(request.Properties))
+	assert.Equal(t, 64, *request.Properties["customerId"].MaxLength)
+	assert.Equal(t, 1, *request.Properties["items"].MinItems)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, request.Properties["priority"].Enum)
+	assert.Equal(t, "#/components/schemas/orderItem", request.Properties["items"].Items.Ref)
+
+	item := schemas["orderItem"]
+	assert.Equal(t, []string{"sku"}, item.Required)
+	assert.Equal(t, "^[A-Z]{3}-[0-9]+$", item.Properties["sku"].Pattern)
+	assert.Equal(t, "integer", item.Properties["quantity"].Type)
+
+	order := schemas["Order"]
+	assert.Equal(t, "#/components/schemas/Order", order.Properties["parent"].Ref)
+	assert.Equal(t, &server.Schema{Type: "string", Format: "date-time"}, order.Properties["createdAt"])
+	assert.Equal(t, &server.Schema{Type: "string"}, order.Properties["labels"].AdditionalProperties)
+	assert.Equal(t, []interface{}{"pending", "paid", "shipped"}, order.Properties["status"].Enum)
+
+	problem := schemas["Problem"]
+	assert.Equal(t, []string{"type", "title", "status", "code"}, problem.Required)
+	assert.Equal(t, "about:blank", problem.Properties["type"].Default)
+	assert.Equal(t, "#/components/schemas/FieldError", problem.Properties["errors"].Items.Ref)
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	router := newDocumentedRouter()
+	// Routes registered after ServeOpenAPI are included
+	router.Get("/late", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := serve(router, http.MethodGet, "/openapi.json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var doc server.OpenAPIDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "Orders", doc.Info.Title)
+	assert.Contains(t, doc.Paths, "/late")
+	assert.NotContains(t, doc.Paths, "/openapi.json")
+
+	rec = serve(router, http.MethodGet, "/openapi.yaml")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var yamlDoc server.OpenAPIDocument
+	require.NoError(t, yaml.Unmarshal(rec.Body.Bytes(), &yamlDoc))
+	encoded, err := json.Marshal(yamlDoc)
+	require.NoError(t, err)
+	assert.JSONEq(t, serve(router, http.MethodGet, "/openapi.json").Body.String(), string(encoded))
+}
+
+func TestRouteDocChecked(t *testing.T) {
+	router := server.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	type badRule struct {
+		Limit int `json:"limit" validate:"min=low"`
+	}
+	assert.Panics(t, func() {
+		router.Get("/orders", ok).Doc(server.RouteDoc{Query: "status"})
+	})
+	assert.Panics(t, func() {
+		router.Get("/items", ok).Doc(server.RouteDoc{Query: badRule{}})
+	})
+	assert.NotPanics(t, func() {
+		router.Get("/health", ok).Doc(server.RouteDoc{Hidden: true, Query: "status"})
+	})
+}
+
+func TestOpenAPISpecIsCurrent(t *testing.T) {
+	doc := newDocumentedRouter().OpenAPI(server.OpenAPIInfo{Title: "Orders", Version: "1.0.0"})
+	servertest.CheckOpenAPI(t, doc, "testdata/openapi.json")
+	servertest.CheckOpenAPI(t, doc, "testdata/openapi.yaml")
+}
+
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
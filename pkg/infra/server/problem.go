@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	apperrors "order-system/pkg/infra/errors"
+)
+
+// ContentTypeProblem is the media type of RFC 7807 problem details
+const ContentTypeProblem = "application/problem+json"
+
+// Error codes with a default status mapping
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeConflict         = "CONFLICT"
+	CodeTooLarge         = "PAYLOAD_TOO_LARGE"
+	CodeUnprocessable    = "UNPROCESSABLE_ENTITY"
+	CodeValidation       = "VALIDATION_ERROR"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeInternal         = "INTERNAL_ERROR"
+	CodeUnavailable      = "SERVICE_UNAVAILABLE"
+	CodeTimeout          = "TIMEOUT"
+)
+
+// problemKey is the context key of the problem writer
+type problemKey struct{}
+
+// DefaultStatuses is the registry used by StandardMiddleware and by
+// WriteError when no writer is installed
+var DefaultStatuses = NewStatusRegistry()
+
+// defaultProblems writes problems when no writer is installed
+var defaultProblems = NewProblemWriter(DefaultStatuses, false)
+
+// StatusRegistry maps error codes to HTTP status codes. Unknown codes map
+// to 500.
+type StatusRegistry struct {
+	mu       sync.RWMutex
+	statuses map[string]int
+}
+
+// NewStatusRegistry creates a registry holding the default mappings
+func NewStatusRegistry() *StatusRegistry {
+	return &StatusRegistry{
+		statuses: map[string]int{
+			CodeBadRequest:       http.StatusBadRequest,
+			CodeUnauthorized:     http.StatusUnauthorized,
+			CodeForbidden:        http.StatusForbidden,
+			CodeNotFound:         http.StatusNotFound,
+			CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+			CodeConflict:         http.StatusConflict,
+			CodeTooLarge:         http.StatusRequestEntityTooLarge,
+			CodeUnprocessable:    http.StatusUnprocessableEntity,
+			CodeValidation:       http.StatusUnprocessableEntity,
+			CodeRateLimited:      http.StatusTooManyRequests,
+			CodeInternal:         http.StatusInternalServerError,
+			CodeUnavailable:      http.StatusServiceUnavailable,
+			CodeTimeout:          http.StatusServiceUnavailable,
+		},
+	}
+}
+
+// Register maps an error code to an HTTP status
+func (r *StatusRegistry) Register(code string, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses[code] = status
+}
+
+// Status returns the HTTP status of an error code
+func (r *StatusRegistry) Status(code string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if status, ok := r.statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Problem represents an RFC 7807 problem details body
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	TraceID  string `json:"traceId,omitempty"`
+	// Errors lists field errors of validation failures
+	Errors ValidationErrors `json:"errors,omitempty"`
+
+	// Debug mode only
+	Cause    string                 `json:"cause,omitempty"`
+	Stack    []string               `json:"stack,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ProblemWriter writes errors as problem+json responses
+type ProblemWriter struct {
+	statuses *StatusRegistry
+	debug    bool
+}
+
+// NewProblemWriter creates a new ProblemWriter. In debug mode responses
+// include the cause, stack and metadata of errors and the message of
+// server errors.
+func NewProblemWriter(statuses *StatusRegistry, debug bool) *ProblemWriter {
+	return &ProblemWriter{
+		statuses: statuses,
+		debug:    debug,
+	}
+}
+
+// Problems installs pw as the writer used by WriteError for the request
+func Problems(pw *ProblemWriter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), problemKey{}, pw)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WriteError writes err as a problem+json response using the writer
+// installed by Problems. Errors that are not an errors.Error are reported
+// as internal errors.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	pw, ok := r.Context().Value(problemKey{}).(*ProblemWriter)
+	if !ok {
+		pw = defaultProblems
+	}
+	pw.Write(w, r, err)
+}
+
+// Write writes err as a problem+json response
+func (p *ProblemWriter) Write(w http.ResponseWriter, r *http.Request, err error) {
+	problem := p.Problem(r, err)
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// Problem builds the problem details of err. A nil err is reported as an
+// internal error, as it means a handler failed without saying why.
+func (p *ProblemWriter) Problem(r *http.Request, err error) *Problem {
+	var appErr *apperrors.Error
+	if err != nil && !errors.As(err, &appErr) {
+		appErr = apperrors.Wrap(err, CodeInternal, "internal server error")
+	}
+	if appErr == nil {
+		appErr = apperrors.New(CodeInternal, "internal server error")
+	}
+
+	status := p.statuses.Status(appErr.Code)
+	problem := &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   appErr.Message,
+		Instance: r.URL.Path,
+		Code:     appErr.Code,
+		TraceID:  TraceID(r.Context()),
+	}
+	var fieldErrs ValidationErrors
+	if errors.As(appErr.Err, &fieldErrs) {
+		problem.Errors = fieldErrs
+	}
+
+	if !p.debug {
+		if status >= http.StatusInternalServerError {
+			problem.Detail = ""
+		}
+		return problem
+	}
+
+	if appErr.Err != nil {
+		problem.Cause = appErr.Err.Error()
+	}
+	if appErr.Stack != "" {
+		problem.Stack = strings.Split(strings.TrimSpace(appErr.Stack), "\n")
+	}
+	if len(appErr.Metadata) > 0 {
+		problem.Metadata = appErr.Metadata
+	}
+	return problem
+}
+
+// TraceID returns the trace ID of the context, falling back to the request ID
+func TraceID(ctx context.Context) string {
+	if id, ok := ctx.Value("trace_id").(string); ok && id != "" {
+		return id
+	}
+	return RequestIDFromContext(ctx)
+}
@@ -0,0 +1,109 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "order-system/pkg/infra/errors"
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProblem(t *testing.T, pw *server.ProblemWriter, err error) (*httptest.ResponseRecorder, map[string]interface{}) {
+	h := server.RequestID()(server.Problems(pw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.WriteError(w, r, err)
+	})))
+
+	rec := serve(h, http.MethodGet, "/orders/1")
+	assert.Equal(t, server.ContentTypeProblem, rec.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec, body
+}
+
+func TestStatusRegistry(t *testing.T) {
+	statuses := server.NewStatusRegistry()
+	assert.Equal(t, http.StatusNotFound, statuses.Status(server.CodeNotFound))
+	assert.Equal(t, http.StatusInternalServerError, statuses.Status("ORDER_UNKNOWN"))
+
+	statuses.Register("ORDER_ALREADY_SHIPPED", http.StatusConflict)
+	assert.Equal(t, http.StatusConflict, statuses.Status("ORDER_ALREADY_SHIPPED"))
+}
+
+func TestProblemClientError(t *testing.T) {
+	statuses := server.NewStatusRegistry()
+	statuses.Register("ORDER_ALREADY_SHIPPED", http.StatusConflict)
+
+	err := apperrors.Wrap(errors.New("row locked"), "ORDER_ALREADY_SHIPPED", "order 1 has already shipped").
+		WithMetadata("orderId", 1)
+	rec, body := writeProblem(t, server.NewProblemWriter(statuses, false), err)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "about:blank", body["type"])
+	assert.Equal(t, "Conflict", body["title"])
+	assert.Equal(t, float64(http.StatusConflict), body["status"])
+	assert.Equal(t, "order 1 has already shipped", body["detail"])
+	assert.Equal(t, "/orders/1", body["instance"])
+	assert.Equal(t, "ORDER_ALREADY_SHIPPED", body["code"])
+	assert.Equal(t, rec.Header().Get(server.HeaderRequestID), body["traceId"])
+
+	assert.NotContains(t, body, "cause")
+	assert.NotContains(t, body, "stack")
+	assert.NotContains(t, body, "metadata")
+}
+
+func TestProblemHidesServerErrorDetails(t *testing.T) {
+	pw := server.NewProblemWriter(server.NewStatusRegistry(), false)
+
+	rec, body := writeProblem(t, pw, apperrors.New(server.CodeInternal, "failed to query orders table"))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, body, "detail")
+
+	rec, body = writeProblem(t, pw, errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, server.CodeInternal, body["code"])
+	assert.NotContains(t, rec.Body.String(), "10.0.0.1")
+}
+
+func TestProblemNilError(t *testing.T) {
+	var nilAppErr *apperrors.Error
+	for _, debug := range []bool{false, true} {
+		pw := server.NewProblemWriter(server.NewStatusRegistry(), debug)
+		for _, err := range []error{nil, nilAppErr} {
+			rec, body := writeProblem(t, pw, err)
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, server.CodeInternal, body["code"])
+		}
+	}
+}
+
+func TestProblemDebugMode(t *testing.T) {
+	pw := server.NewProblemWriter(server.NewStatusRegistry(), true)
+
+	err := apperrors.Wrap(errors.New("connection refused"), server.CodeUnavailable, "database unavailable").
+		WithMetadata("host", "db-1")
+	rec, body := writeProblem(t, pw, err)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "database unavailable", body["detail"])
+	assert.Equal(t, "connection refused", body["cause"])
+	assert.NotEmpty(t, body["stack"])
+	assert.Equal(t, map[string]interface{}{"host": "db-1"}, body["metadata"])
+}
+
+func TestWriteErrorDefaultWriter(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.WriteError(w, r, apperrors.New(server.CodeForbidden, "not your order"))
+	})
+
+	rec := serve(h, http.MethodGet, "/orders/1")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, server.ContentTypeProblem, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "not your order")
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+	"order-system/pkg/platform/metrics"
+)
+
+// MetricRateLimited counts requests rejected by the rate limiter
+const MetricRateLimited = "http_server_rate_limited_total"
+
+// bucketSweepInterval is the interval of dropping refilled buckets
+const bucketSweepInterval = time.Minute
+
+// apiKeyKey is the context key of an authenticated API key
+type apiKeyKey struct{}
+
+// WithAPIKey returns a context carrying an API key. Authentication
+// middleware calls it once the key is verified, so that RateLimit applies
+// the quota of the key; unverified keys must not be stored.
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the authenticated API key stored by WithAPIKey
+func APIKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// bucket is a token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	quota   quota
+}
+
+// quota represents a parsed rate limit quota
+type quota struct {
+	name  string
+	rate  float64 // tokens per second
+	burst float64
+}
+
+// rateLimiter holds the buckets of every client and quota
+type rateLimiter struct {
+	cfg       config.RateLimitConfig
+	collector metrics.Collector
+	proxies   []*net.IPNet
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// RateLimit applies token-bucket quotas from config.RateLimit per client
+// and route. Clients are identified by the API key stored with WithAPIKey,
+// the subject of verified JWT claims or their IP address, so authentication
+// middleware must run first. Rejected requests get 429 with Retry-After; every limited
+// response carries RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset.
+// Rejections are counted in collector, which may be nil. The router's route
+// templates are used, so the middleware must run inside a Router. It panics
+// if the rejection metric is registered with another type.
+func RateLimit(cfg config.RateLimitConfig, collector metrics.Collector) Middleware {
+	if collector != nil {
+		if err := metrics.EnsureRegistered(collector, MetricRateLimited, metrics.Counter, "Total number of requests rejected by rate limiting"); err != nil {
+			panic(fmt.Sprintf("server: %v", err))
+		}
+	}
+	l := &rateLimiter{
+		cfg:       cfg,
+		collector: collector,
+		proxies:   parseProxies(cfg.TrustedProxies),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q, ok := l.quota(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			keyType, key := l.clientKey(r)
+			allowed, remaining, retryAfter, reset := l.take(q.name+"|"+keyType+":"+key, q)
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(int(q.burst)))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+			if allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if l.collector != nil {
+				l.collector.IncrementCounter(MetricRateLimited, 1, metrics.Labels{
+					"method":   r.Method,
+					"route":    RouteTemplate(r),
+					"key_type": keyType,
+				})
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			WriteError(w, r, apperrors.New(CodeRateLimited, "rate limit exceeded"))
+		})
+	}
+}
+
+// quota returns the quota applying to a request
+func (l *rateLimiter) quota(r *http.Request) (quota, bool) {
+	route := RouteTemplate(r)
+	for i, rule := range l.cfg.Routes {
+		if rule.Route != route || (rule.Method != "" && !strings.EqualFold(rule.Method, r.Method)) {
+			continue
+		}
+		return parseQuota("route"+strconv.Itoa(i), rule.Quota)
+	}
+	return parseQuota("default", l.cfg.Default)
+}
+
+// parseQuota converts a configured quota, reporting false if it is disabled
+func parseQuota(name string, q config.RateLimitQuota) (quota, bool) {
+	if q.Requests <= 0 || q.Period <= 0 {
+		return quota{}, false
+	}
+	burst := q.Burst
+	if burst <= 0 {
+		burst = q.Requests
+	}
+	return quota{
+		name:  name,
+		rate:  float64(q.Requests) / q.Period.Seconds(),
+		burst: float64(burst),
+	}, true
+}
+
+// clientKey identifies the client by authenticated API key, authenticated
+// user or IP. Credentials presented but not verified are ignored, so they
+// cannot be rotated to evade quotas or forged to drain another client's.
+func (l *rateLimiter) clientKey(r *http.Request) (string, string) {
+	if key, ok := APIKeyFromContext(r.Context()); ok {
+		return "api_key", key
+	}
+	if claims, ok := ClaimsFromContext(r.Context()); ok && claims.Subject != "" {
+		return "user", claims.Subject
+	}
+	return "ip", clientIP(r, l.proxies)
+}
+
+// take removes a token from a bucket. It returns whether the request is
+// allowed, the remaining tokens, the wait until the next token and the wait
+// until the bucket is full.
+func (l *rateLimiter) take(key string, q quota) (bool, int, time.Duration, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= bucketSweepInterval {
+		l.sweepLocked(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: q.burst, updated: now, quota: q}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(q.burst, b.tokens+now.Sub(b.updated).Seconds()*q.rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	untilFull := time.Duration((q.burst - b.tokens) / q.rate * float64(time.Second))
+	var untilNext time.Duration
+	if b.tokens < 1 {
+		untilNext = time.Duration((1 - b.tokens) / q.rate * float64(time.Second))
+	}
+	return allowed, int(b.tokens), untilNext, untilFull
+}
+
+// sweepLocked drops buckets that have refilled, since they are equivalent
+// to new ones, bounding memory use; l.mu must be held
+func (l *rateLimiter) sweepLocked(now time.Time) {
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.quota.rate >= b.quota.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP returns the IP address of the client. Requests from trusted
+// proxies are attributed to the rightmost X-Forwarded-For hop that is not a
+// trusted proxy, since hops further left are supplied by the client.
+func clientIP(r *http.Request, proxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !trusted(ip, proxies) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// Malformed entries end the trusted chain at the last proxy
+			return ip
+		}
+		ip = hop
+		if !trusted(ip, proxies) {
+			break
+		}
+	}
+	return ip
+}
+
+// trusted reports whether ip belongs to a trusted proxy
+func trusted(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProxies converts trusted proxy IPs and CIDRs to networks, skipping
+// invalid entries rejected by config validation
+func parseProxies(entries []string) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range entries {
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			proxies = append(proxies, network)
+		}
+	}
+	return proxies
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,221 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/server"
+	"order-system/pkg/platform/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetricsCollector(t *testing.T) metrics.Collector {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, err := metrics.New(cfg)
+	require.NoError(t, err)
+	return collector
+}
+
+func newRateLimitedRouter(cfg config.RateLimitConfig, collector metrics.Collector) *server.Router {
+	router := server.NewRouter()
+	router.Use(server.RateLimit(cfg, collector))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.Get("/orders", ok)
+	router.Post("/orders", ok)
+	router.Get("/orders/{id}", ok)
+	return router
+}
+
+func limitedRequest(h http.Handler, method, path string, setup func(r *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if setup != nil {
+		setup(req)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitRejects(t *testing.T) {
+	collector := newMetricsCollector(t)
+	router := newRateLimitedRouter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitQuota{Requests: 2, Period: time.Minute},
+	}, collector)
+
+	rec := limitedRequest(router, http.MethodGet, "/orders/1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders/2", nil).Code)
+
+	rec = limitedRequest(router, http.MethodGet, "/orders/3", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 30, retryAfter, 1)
+	assert.Equal(t, server.ContentTypeProblem, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), server.CodeRateLimited)
+
+	assert.Equal(t, float64(1), collector.GetCounter(server.MetricRateLimited, metrics.Labels{
+		"method":   http.MethodGet,
+		"route":    "/orders/{id}",
+		"key_type": "ip",
+	}))
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	router := server.NewRouter()
+	// Stands in for authentication middleware verifying API keys
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := r.Header.Get("X-API-Key"); key == "a" || key == "b" {
+				r = r.WithContext(server.WithAPIKey(r.Context(), key))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, server.RateLimit(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitQuota{Requests: 1, Period: time.Minute},
+	}, nil))
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+
+	withKey := func(key string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("X-API-Key", key) }
+	}
+
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", withKey("a")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodGet, "/orders", withKey("a")).Code)
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", withKey("b")).Code)
+}
+
+func TestRateLimitIgnoresUnverifiedKeys(t *testing.T) {
+	router := newRateLimitedRouter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitQuota{Requests: 1, Period: time.Minute},
+	}, nil)
+
+	// Rotating keys that were never authenticated share the IP bucket
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		rec := limitedRequest(router, http.MethodGet, "/orders", func(r *http.Request) {
+			r.Header.Set("X-API-Key", "random-"+strconv.Itoa(i))
+		})
+		assert.Equal(t, want, rec.Code)
+	}
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	router := newRateLimitedRouter(config.RateLimitConfig{
+		Enabled:        true,
+		TrustedProxies: []string{"192.0.2.1", "10.0.0.0/8"},
+		Default:        config.RateLimitQuota{Requests: 1, Period: time.Minute},
+	}, nil)
+
+	forwardedFor := func(hops string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("X-Forwarded-For", hops) }
+	}
+
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", forwardedFor("198.51.100.1, 10.0.0.1")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodGet, "/orders", forwardedFor("198.51.100.1")).Code)
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", forwardedFor("198.51.100.2, 10.0.0.1")).Code)
+
+	// Hops left of the first untrusted one are chosen by the client
+	spoofed := forwardedFor("203.0.113.9, 198.51.100.1, 10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodGet, "/orders", spoofed).Code)
+
+	// Requests not coming from a trusted proxy are keyed by their address
+	untrusted := func(r *http.Request) {
+		r.RemoteAddr = "198.51.100.3:1234"
+		r.Header.Set("X-Forwarded-For", "203.0.113.10")
+	}
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", untrusted).Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodGet, "/orders", untrusted).Code)
+}
+
+func TestRateLimitUserKey(t *testing.T) {
+	verifier, err := server.NewJWTVerifier(server.JWTConfig{Secret: jwtSecret})
+	require.NoError(t, err)
+
+	router := server.NewRouter()
+	router.Use(verifier.Middleware(), server.RateLimit(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitQuota{Requests: 1, Period: time.Minute},
+	}, nil))
+	router.Get("/orders", func(w http.ResponseWriter, r *http.Request) {})
+
+	asUser := func(sub string) func(r *http.Request) {
+		claims := validClaims()
+		claims["sub"] = sub
+		token := signToken(t, "HS256", "", jwtSecret, claims)
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	// Users sharing an IP address have separate buckets
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", asUser("alice")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodGet, "/orders", asUser("alice")).Code)
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", asUser("bob")).Code)
+}
+
+func TestRateLimitRouteQuotas(t *testing.T) {
+	router := newRateLimitedRouter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitQuota{Requests: 100, Period: time.Minute},
+		Routes: []config.RateLimitRoute{
+			{Method: http.MethodPost, Route: "/orders", Quota: config.RateLimitQuota{Requests: 1, Period: time.Minute}},
+		},
+	}, nil)
+
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodPost, "/orders", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodPost, "/orders", nil).Code)
+
+	rec := limitedRequest(router, http.MethodGet, "/orders", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "100", rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitRefill(t *testing.T) {
+	router := newRateLimitedRouter(config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitQuota{Requests: 1, Period: 50 * time.Millisecond},
+	}, nil)
+
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(router, http.MethodGet, "/orders", nil).Code)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, limitedRequest(router, http.MethodGet, "/orders", nil).Code)
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	router := newRateLimitedRouter(config.RateLimitConfig{
+		Default: config.RateLimitQuota{Requests: 1, Period: time.Minute},
+	}, nil)
+
+	for i := 0; i < 3; i++ {
+		rec := limitedRequest(router, http.MethodGet, "/orders", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimitMetricTypeConflict(t *testing.T) {
+	collector := newMetricsCollector(t)
+	require.NoError(t, collector.Register(server.MetricRateLimited, metrics.Gauge, ""))
+
+	// A shared registration is fine, another type is a programming error
+	shared := newMetricsCollector(t)
+	server.RateLimit(config.RateLimitConfig{}, shared)
+	assert.NotPanics(t, func() { server.RateLimit(config.RateLimitConfig{}, shared) })
+	assert.Panics(t, func() { server.RateLimit(config.RateLimitConfig{}, collector) })
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	apperrors "order-system/pkg/infra/errors"
+)
+
+// Middleware wraps an http.Handler
+type Middleware func(http.Handler) http.Handler
+
+// routeKey is the context key of the matched route
+type routeKey struct{}
+
+// routeInfo represents the route matched for a request
+type routeInfo struct {
+	template string
+	params   map[string]string
+}
+
+// Route represents a handler registered for a method and pattern
+type Route struct {
+	method   string
+	template string
+	handler  http.Handler
+	group    *RouteGroup
+	doc      *RouteDoc
+}
+
+// node is a path segment in the routing tree
+type node struct {
+	static    map[string]*node
+	param     *node
+	catchAll  *node
+	paramName string
+	routes    map[string]*Route
+}
+
+// Router dispatches requests by method and path. Patterns consist of static
+// segments, {name} parameters matching one segment and a trailing {name...}
+// parameter matching the rest of the path; static segments take precedence.
+// The route is matched before any middleware runs, so middleware can read
+// the route template and parameters.
+type Router struct {
+	*RouteGroup
+	root     *node
+	notFound http.Handler
+	// routes lists the registered routes in order of registration
+	routes []*Route
+}
+
+// RouteGroup is a set of routes sharing a path prefix and middleware
+type RouteGroup struct {
+	router      *Router
+	parent      *RouteGroup
+	prefix      string
+	middlewares []Middleware
+}
+
+// NewRouter creates a new Router
+func NewRouter() *Router {
+	r := &Router{root: &node{}}
+	r.RouteGroup = &RouteGroup{router: r}
+	r.notFound = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		WriteError(w, req, apperrors.New(CodeNotFound, "resource not found"))
+	})
+	return r
+}
+
+// NotFound sets the handler for requests matching no route
+func (r *Router) NotFound(h http.Handler) {
+	r.notFound = h
+}
+
+// ServeHTTP implements http.Handler
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	params := make(map[string]string)
+	n := r.root.lookup(splitPath(req.URL.EscapedPath()), params)
+
+	info := &routeInfo{}
+	group := r.RouteGroup
+	var handler http.Handler
+
+	switch {
+	case n == nil || len(n.routes) == 0:
+		handler = r.notFound
+	case n.route(req.Method) == nil:
+		handler = methodNotAllowed(n.allowed())
+	default:
+		rt := n.route(req.Method)
+		info.template = rt.template
+		info.params = params
+		group = rt.group
+		handler = rt.handler
+	}
+
+	ctx := context.WithValue(req.Context(), routeKey{}, info)
+	group.chain(handler).ServeHTTP(w, req.WithContext(ctx))
+}
+
+// Param returns the value of a path parameter of the matched route
+func Param(r *http.Request, name string) string {
+	if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+		return info.params[name]
+	}
+	return ""
+}
+
+// RouteTemplate returns the pattern of the matched route, e.g.
+// "/orders/{id}", or "" if no route matched
+func RouteTemplate(r *http.Request) string {
+	if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+		return info.template
+	}
+	return ""
+}
+
+// Group creates a sub-group with an additional prefix and middleware
+func (g *RouteGroup) Group(prefix string, mw ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		router:      g.router,
+		parent:      g,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: mw,
+	}
+}
+
+// Use appends middleware to the group. Middleware of the router itself
+// also runs for unmatched requests.
+func (g *RouteGroup) Use(mw ...Middleware) {
+	g.middlewares = append(g.middlewares, mw...)
+}
+
+// Handle registers a handler for a method and pattern. It panics if the
+// pattern is invalid or already registered for the method.
+func (g *RouteGroup) Handle(method, pattern string, h http.Handler) *Route {
+	template := g.prefix + pattern
+	if template == "" {
+		template = "/"
+	}
+	if !strings.HasPrefix(template, "/") {
+		panic(fmt.Sprintf("server: pattern %q must start with /", template))
+	}
+
+	n := g.router.root
+	segments := splitPath(template)
+	for i, segment := range segments {
+		n = n.child(template, segment, i == len(segments)-1)
+	}
+
+	if n.routes == nil {
+		n.routes = make(map[string]*Route)
+	}
+	if _, ok := n.routes[method]; ok {
+		panic(fmt.Sprintf("server: %s %s is already registered", method, template))
+	}
+	rt := &Route{method: method, template: template, handler: h, group: g}
+	n.routes[method] = rt
+	g.router.routes = append(g.router.routes, rt)
+	return rt
+}
+
+// Doc documents the route in the OpenAPI document. It panics if doc cannot
+// be documented, e.g. on an invalid validation rule, so that mistakes show
+// at registration rather than when the document is first served.
+func (rt *Route) Doc(doc RouteDoc) *Route {
+	rt.doc = &doc
+	if !doc.Hidden {
+		_, params := openAPIPath(rt.template)
+		newOpenAPIBuilder().operation(rt, params)
+	}
+	return rt
+}
+
+// HandleFunc registers a handler function for a method and pattern
+func (g *RouteGroup) HandleFunc(method, pattern string, h http.HandlerFunc) *Route {
+	return g.Handle(method, pattern, h)
+}
+
+// Get registers a GET handler
+func (g *RouteGroup) Get(pattern string, h http.HandlerFunc) *Route {
+	return g.Handle(http.MethodGet, pattern, h)
+}
+
+// Post registers a POST handler
+func (g *RouteGroup) Post(pattern string, h http.HandlerFunc) *Route {
+	return g.Handle(http.MethodPost, pattern, h)
+}
+
+// Put registers a PUT handler
+func (g *RouteGroup) Put(pattern string, h http.HandlerFunc) *Route {
+	return g.Handle(http.MethodPut, pattern, h)
+}
+
+// Patch registers a PATCH handler
+func (g *RouteGroup) Patch(pattern string, h http.HandlerFunc) *Route {
+	return g.Handle(http.MethodPatch, pattern, h)
+}
+
+// Delete registers a DELETE handler
+func (g *RouteGroup) Delete(pattern string, h http.HandlerFunc) *Route {
+	return g.Handle(http.MethodDelete, pattern, h)
+}
+
+// chain wraps h with the middleware of the group and its parents, the
+// router's middleware being outermost
+func (g *RouteGroup) chain(h http.Handler) http.Handler {
+	for group := g; group != nil; group = group.parent {
+		for i := len(group.middlewares) - 1; i >= 0; i-- {
+			h = group.middlewares[i](h)
+		}
+	}
+	return h
+}
+
+// child returns the node for a pattern segment, creating it if needed
+func (n *node) child(template, segment string, last bool) *node {
+	if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		if n.static == nil {
+			n.static = make(map[string]*node)
+		}
+		child, ok := n.static[segment]
+		if !ok {
+			child = &node{}
+			n.static[segment] = child
+		}
+		return child
+	}
+
+	name := segment[1 : len(segment)-1]
+	target := &n.param
+	if strings.HasSuffix(name, "...") {
+		if !last {
+			panic(fmt.Sprintf("server: %s in %q must be the last segment", segment, template))
+		}
+		name = strings.TrimSuffix(name, "...")
+		target = &n.catchAll
+	}
+	if name == "" {
+		panic(fmt.Sprintf("server: empty parameter name in %q", template))
+	}
+
+	if *target == nil {
+		*target = &node{paramName: name}
+	} else if (*target).paramName != name {
+		panic(fmt.Sprintf("server: parameter {%s} in %q conflicts with {%s}", name, template, (*target).paramName))
+	}
+	return *target
+}
+
+// lookup finds the node matching segments, filling params
+func (n *node) lookup(segments []string, params map[string]string) *node {
+	if len(segments) == 0 {
+		if len(n.routes) > 0 || n.catchAll == nil {
+			return n
+		}
+		params[n.catchAll.paramName] = ""
+		return n.catchAll
+	}
+
+	segment, rest := segments[0], segments[1:]
+	if child, ok := n.static[segment]; ok {
+		if found := child.lookup(rest, params); found != nil && len(found.routes) > 0 {
+			return found
+		}
+	}
+	if n.param != nil {
+		value, err := url.PathUnescape(segment)
+		if err == nil && value != "" {
+			if found := n.param.lookup(rest, params); found != nil && len(found.routes) > 0 {
+				params[n.param.paramName] = value
+				return found
+			}
+		}
+	}
+	if n.catchAll != nil {
+		value, err := url.PathUnescape(strings.Join(segments, "/"))
+		if err == nil {
+			params[n.catchAll.paramName] = value
+			return n.catchAll
+		}
+	}
+	return nil
+}
+
+// route returns the route for a method; HEAD falls back to GET
+func (n *node) route(method string) *Route {
+	if rt, ok := n.routes[method]; ok {
+		return rt
+	}
+	if method == http.MethodHead {
+		return n.routes[http.MethodGet]
+	}
+	return nil
+}
+
+// allowed returns the methods registered on the node
+func (n *node) allowed() []string {
+	methods := make([]string, 0, len(n.routes)+1)
+	for method := range n.routes {
+		methods = append(methods, method)
+	}
+	if _, ok := n.routes[http.MethodGet]; ok {
+		if _, ok := n.routes[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// methodNotAllowed responds with 405 and the Allow header
+func methodNotAllowed(methods []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		WriteError(w, r, apperrors.New(CodeMethodNotAllowed, "method not allowed"))
+	})
+}
+
+// splitPath splits a path into segments, ignoring leading and trailing slashes
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func echoRoute(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(server.RouteTemplate(r) + " id=" + server.Param(r, "id") + " path=" + server.Param(r, "path")))
+}
+
+func TestRouterParams(t *testing.T) {
+	router := server.NewRouter()
+	router.Get("/orders", echoRoute)
+	router.Get("/orders/{id}", echoRoute)
+	router.Get("/orders/latest", echoRoute)
+	router.Get("/files/{path...}", echoRoute)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/orders", "/orders id= path="},
+		{"/orders/", "/orders id= path="},
+		{"/orders/42", "/orders/{id} id=42 path="},
+		{"/orders/a%2Fb", "/orders/{id} id=a/b path="},
+		{"/orders/latest", "/orders/latest id= path="},
+		{"/files/a/b/c.txt", "/files/{path...} id= path=a/b/c.txt"},
+		{"/files", "/files/{path...} id= path="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, tt.path)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestRouterStaticFallsBackToParam(t *testing.T) {
+	router := server.NewRouter()
+	router.Get("/orders/latest/items", echoRoute)
+	router.Get("/orders/{id}/status", echoRoute)
+
+	rec := serve(router, http.MethodGet, "/orders/latest/status")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/orders/{id}/status id=latest path=", rec.Body.String())
+}
+
+func TestRouterMethodMatching(t *testing.T) {
+	router := server.NewRouter()
+	router.Get("/orders/{id}", echoRoute)
+	router.Delete("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.Equal(t, http.StatusNoContent, serve(router, http.MethodDelete, "/orders/1").Code)
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodHead, "/orders/1").Code)
+
+	rec := serve(router, http.MethodPost, "/orders/1")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "DELETE, GET, HEAD", rec.Header().Get("Allow"))
+
+	rec = serve(router, http.MethodGet, "/customers/1")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "NOT_FOUND")
+}
+
+func TestRouterGroups(t *testing.T) {
+	var calls []string
+	tag := func(name string) server.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+":"+server.RouteTemplate(r))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	router := server.NewRouter()
+	router.Use(tag("router"))
+	api := router.Group("/api/v1", tag("api"))
+	orders := api.Group("/orders")
+	orders.Use(tag("orders"))
+	orders.Get("/{id}", echoRoute)
+
+	rec := serve(router, http.MethodGet, "/api/v1/orders/7")
+	assert.Equal(t, "/api/v1/orders/{id} id=7 path=", rec.Body.String())
+	assert.Equal(t, []string{
+		"router:/api/v1/orders/{id}",
+		"api:/api/v1/orders/{id}",
+		"orders:/api/v1/orders/{id}",
+	}, calls)
+
+	// Router middleware also runs for unmatched requests
+	calls = nil
+	assert.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/api/v2").Code)
+	assert.Equal(t, []string{"router:"}, calls)
+}
+
+func TestRouterInvalidPatterns(t *testing.T) {
+	router := server.NewRouter()
+	router.Get("/orders/{id}", echoRoute)
+
+	assert.Panics(t, func() { router.Get("/orders/{id}", echoRoute) })
+	assert.Panics(t, func() { router.Get("/orders/{orderID}/items", echoRoute) })
+	assert.Panics(t, func() { router.Get("/files/{path...}/raw", echoRoute) })
+	assert.Panics(t, func() { router.Get("orders", echoRoute) })
+}
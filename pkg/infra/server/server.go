@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/logger"
+)
+
+// Server is an HTTP server built from config.HTTP with graceful shutdown
+type Server struct {
+	server   *http.Server
+	config   *config.Config
+	listener Listener
+	log      logger.Logger
+	signals  []os.Signal
+
+	mu         sync.Mutex
+	ln         net.Listener
+	serveErr   chan error
+	onShutdown []func()
+}
+
+// New creates a new Server serving handler
+func New(cfg *config.Config, handler http.Handler, opts ...Option) *Server {
+	s := &Server{
+		server: &http.Server{
+			Addr:           fmt.Sprintf(":%d", cfg.HTTP.Port),
+			Handler:        handler,
+			ReadTimeout:    cfg.HTTP.ReadTimeout,
+			WriteTimeout:   cfg.HTTP.WriteTimeout,
+			MaxHeaderBytes: cfg.HTTP.MaxHeaderBytes,
+		},
+		config:  cfg,
+		signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.listener == nil {
+		s.listener = NewTCPListener(s.server.Addr)
+	}
+	return s
+}
+
+// Start binds the listener and serves requests in the background
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ln != nil {
+		return fmt.Errorf("server already started")
+	}
+	ln, err := s.listener.Listen()
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+	s.serveErr = make(chan error, 1)
+
+	go func() {
+		err := s.server.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		s.serveErr <- err
+	}()
+
+	s.info("server started", logger.Field{Key: "addr", Value: ln.Addr().String()})
+	return nil
+}
+
+// Addr returns the address the server is bound to, or "" before Start
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ln == nil {
+		return ""
+	}
+	return s.ln.Addr().String()
+}
+
+// OnShutdown registers a function called when graceful shutdown begins,
+// before in-flight requests are drained
+func (s *Server) OnShutdown(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests,
+// bounded by config.HTTP.ShutdownTimeout. Connections still open when the
+// timeout expires are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := append([]func(){}, s.onShutdown...)
+	s.mu.Unlock()
+
+	s.info("server shutting down")
+	for _, fn := range hooks {
+		fn()
+	}
+
+	timeout := s.config.HTTP.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	return nil
+}
+
+// Run starts the server and blocks until ctx is done, a shutdown signal is
+// received or the server fails, then shuts down gracefully
+func (s *Server) Run(ctx context.Context) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, s.signals...)
+	defer signal.Stop(sigCh)
+
+	if err := s.Start(); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-s.serveErr:
+		return err
+	case sig := <-sigCh:
+		s.info("received signal", logger.Field{Key: "signal", Value: sig.String()})
+	case <-ctx.Done():
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		return err
+	}
+	return <-s.serveErr
+}
+
+// info logs a lifecycle event if a logger is configured
+func (s *Server) info(msg string, fields ...logger.Field) {
+	if s.log != nil {
+		s.log.Info(context.Background(), msg, fields...)
+	}
+}
@@ -0,0 +1,177 @@
+package server_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.HTTP.ReadTimeout = 5 * time.Second
+	cfg.HTTP.WriteTimeout = 5 * time.Second
+	cfg.HTTP.ShutdownTimeout = 5 * time.Second
+	return cfg
+}
+
+func waitForAddr(t *testing.T, srv *server.Server) string {
+	require.Eventually(t, func() bool { return srv.Addr() != "" }, time.Second, 5*time.Millisecond)
+	return srv.Addr()
+}
+
+func TestServerStart(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	srv := server.New(newTestConfig(), handler, server.WithListener(server.NewRandomListener()))
+	assert.Empty(t, srv.Addr())
+
+	require.NoError(t, srv.Start())
+	defer srv.Shutdown(context.Background())
+	assert.Error(t, srv.Start())
+
+	resp, err := http.Get("http://" + srv.Addr() + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	srv := server.New(newTestConfig(), handler, server.WithListener(server.NewRandomListener()))
+	require.NoError(t, srv.Start())
+
+	var hookCalled bool
+	srv.OnShutdown(func() { hookCalled = true })
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + srv.Addr() + "/")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+
+	<-started
+	require.NoError(t, srv.Shutdown(context.Background()))
+	assert.True(t, hookCalled)
+	assert.Equal(t, "done", <-result)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	cfg := newTestConfig()
+	cfg.HTTP.ShutdownTimeout = 50 * time.Millisecond
+	srv := server.New(cfg, handler, server.WithListener(server.NewRandomListener()))
+	require.NoError(t, srv.Start())
+
+	go http.Get("http://" + srv.Addr() + "/")
+	<-started
+
+	start := time.Now()
+	err := srv.Shutdown(context.Background())
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestServerWriteTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("profile"))
+	})
+
+	cfg := newTestConfig()
+	cfg.HTTP.WriteTimeout = 20 * time.Millisecond
+	get := func(srv *server.Server) (string, error) {
+		require.NoError(t, srv.Start())
+		defer srv.Shutdown(context.Background())
+
+		resp, err := http.Get("http://" + srv.Addr() + "/")
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	_, err := get(server.New(cfg, handler, server.WithListener(server.NewRandomListener())))
+	assert.Error(t, err)
+
+	body, err := get(server.New(cfg, handler,
+		server.WithListener(server.NewRandomListener()),
+		server.WithWriteTimeout(0),
+	))
+	require.NoError(t, err)
+	assert.Equal(t, "profile", body)
+}
+
+func TestServerRunContextCancel(t *testing.T) {
+	srv := server.New(newTestConfig(), http.NotFoundHandler(), server.WithListener(server.NewRandomListener()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	waitForAddr(t, srv)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+func TestServerRunSignal(t *testing.T) {
+	srv := server.New(newTestConfig(), http.NotFoundHandler(), server.WithListener(server.NewRandomListener()))
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(context.Background()) }()
+
+	waitForAddr(t, srv)
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("server did not stop on SIGTERM")
+	}
+}
+
+func TestServerListenError(t *testing.T) {
+	first := server.New(newTestConfig(), http.NotFoundHandler(), server.WithListener(server.NewRandomListener()))
+	require.NoError(t, first.Start())
+	defer first.Shutdown(context.Background())
+
+	second := server.New(newTestConfig(), http.NotFoundHandler(), server.WithListener(server.NewTCPListener(first.Addr())))
+	assert.Error(t, second.Start())
+}
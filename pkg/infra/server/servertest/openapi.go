@@ -0,0 +1,63 @@
+// Package servertest provides test helpers for servers built with package server
+package servertest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// UpdateEnv is the environment variable that makes CheckOpenAPI rewrite
+// stale documents instead of failing
+const UpdateEnv = "UPDATE_OPENAPI"
+
+// CheckOpenAPI fails the test if the document checked in at path differs
+// from doc. The format follows the extension of path: .yaml or .yml for
+// YAML, JSON otherwise. Run the test with UPDATE_OPENAPI=1 to regenerate
+// the file.
+func CheckOpenAPI(t testing.TB, doc *server.OpenAPIDocument, path string) {
+	t.Helper()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	yamlFormat := ext == ".yaml" || ext == ".yml"
+	encode := doc.JSON
+	if yamlFormat {
+		encode = doc.YAML
+	}
+	generated, err := encode()
+	if err != nil {
+		t.Fatalf("failed to encode OpenAPI document: %v", err)
+		return
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+			return
+		}
+		if err := os.WriteFile(path, generated, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+			return
+		}
+		t.Logf("updated %s", path)
+		return
+	}
+
+	checkedIn, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v; run the test with %s=1 to create it", path, err, UpdateEnv)
+		return
+	}
+
+	msg := path + " is stale; run the test with " + UpdateEnv + "=1 to regenerate it"
+	if yamlFormat {
+		assert.YAMLEq(t, string(generated), string(checkedIn), msg)
+	} else {
+		assert.JSONEq(t, string(generated), string(checkedIn), msg)
+	}
+}
@@ -0,0 +1,87 @@
+package servertest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"order-system/pkg/infra/server"
+	"order-system/pkg/infra/server/servertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTB implements testing.TB by recording failures
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (t *recordingTB) Helper() {}
+
+func (t *recordingTB) Name() string { return "recording" }
+
+func (t *recordingTB) Logf(format string, args ...interface{}) {}
+
+func (t *recordingTB) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingTB) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+}
+
+func newDocument(paths ...string) *server.OpenAPIDocument {
+	router := server.NewRouter()
+	for _, path := range paths {
+		router.Get(path, func(w http.ResponseWriter, r *http.Request) {})
+	}
+	return router.OpenAPI(server.OpenAPIInfo{Title: "Orders", Version: "1.0.0"})
+}
+
+func TestCheckOpenAPI(t *testing.T) {
+	for _, name := range []string{"openapi.json", "openapi.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "api", name)
+
+			// A missing document fails
+			tb := &recordingTB{}
+			servertest.CheckOpenAPI(tb, newDocument("/orders"), path)
+			require.Len(t, tb.failures, 1)
+			assert.Contains(t, tb.failures[0], servertest.UpdateEnv+"=1")
+
+			t.Setenv(servertest.UpdateEnv, "1")
+			servertest.CheckOpenAPI(t, newDocument("/orders"), path)
+			t.Setenv(servertest.UpdateEnv, "")
+
+			tb = &recordingTB{}
+			servertest.CheckOpenAPI(tb, newDocument("/orders"), path)
+			assert.Empty(t, tb.failures)
+
+			// A new route makes the checked-in document stale
+			tb = &recordingTB{}
+			servertest.CheckOpenAPI(tb, newDocument("/orders", "/orders/{id}"), path)
+			require.Len(t, tb.failures, 1)
+			assert.Contains(t, tb.failures[0], "is stale")
+			assert.Contains(t, tb.failures[0], "/orders/{id}")
+		})
+	}
+}
+
+func TestCheckOpenAPIIgnoresFormatting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	data, err := newDocument("/orders").JSON()
+	require.NoError(t, err)
+	var compact bytes.Buffer
+	require.NoError(t, json.Compact(&compact, data))
+	require.NoError(t, os.WriteFile(path, compact.Bytes(), 0644))
+
+	tb := &recordingTB{}
+	servertest.CheckOpenAPI(tb, newDocument("/orders"), path)
+	assert.Empty(t, tb.failures)
+}
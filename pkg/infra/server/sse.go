@@ -0,0 +1,364 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "order-system/pkg/infra/errors"
+)
+
+const (
+	// ContentTypeEventStream is the content type of server-sent events
+	ContentTypeEventStream = "text/event-stream"
+	// HeaderLastEventID carries the last event seen by a reconnecting client
+	HeaderLastEventID = "Last-Event-ID"
+	// EventReset is sent to resuming clients that missed events no longer
+	// held in the replay buffer; they should reload their state
+	EventReset = "reset"
+)
+
+const (
+	defaultStreamBuffer    = 64
+	defaultReplaySize      = 1024
+	defaultHeartbeat       = 15 * time.Second
+	defaultStreamWriteTime = 10 * time.Second
+)
+
+// StreamConfig represents the settings of an event broker
+type StreamConfig struct {
+	// BufferSize is the number of events queued for a connection; clients
+	// falling further behind are evicted. Defaults to 64.
+	BufferSize int
+	// ReplaySize is the number of recent events kept for Last-Event-ID
+	// resume. Defaults to 1024.
+	ReplaySize int
+	// Heartbeat is the interval of comments keeping idle connections open.
+	// Defaults to 15s.
+	Heartbeat time.Duration
+	// WriteTimeout bounds every write to a client. Defaults to 10s.
+	WriteTimeout time.Duration
+	// Retry is the reconnection delay advised to clients; zero leaves the
+	// client default
+	Retry time.Duration
+}
+
+// Event represents a server-sent event. Topic selects the streams the event
+// is delivered to and is not sent to clients.
+type Event struct {
+	ID    string
+	Topic string
+	Type  string
+	Data  []byte
+}
+
+// EventBroker is an in-process publisher fanning events out to event
+// stream connections. Register Close with Server.OnShutdown so that open
+// streams do not hold up graceful shutdown.
+type EventBroker struct {
+	cfg StreamConfig
+	// epoch distinguishes event IDs of different broker instances, so that
+	// IDs from before a restart are detected as a gap
+	epoch string
+
+	mu      sync.Mutex
+	seq     uint64
+	replay  []sequencedEvent
+	next    int
+	clients map[*streamClient]struct{}
+	closed  bool
+}
+
+// sequencedEvent represents an event and its position in the stream
+type sequencedEvent struct {
+	seq   uint64
+	event Event
+}
+
+// streamClient represents a connected stream
+type streamClient struct {
+	topic  string
+	events chan Event
+	// gone is closed when the client is evicted or the broker is closed
+	gone chan struct{}
+}
+
+// NewEventBroker creates a new EventBroker
+func NewEventBroker(cfg StreamConfig) *EventBroker {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultStreamBuffer
+	}
+	if cfg.ReplaySize <= 0 {
+		cfg.ReplaySize = defaultReplaySize
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = defaultHeartbeat
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaultStreamWriteTime
+	}
+
+	return &EventBroker{
+		cfg:     cfg,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		replay:  make([]sequencedEvent, 0, cfg.ReplaySize),
+		clients: make(map[*streamClient]struct{}),
+	}
+}
+
+// Publish sends an event to the streams of topic and to unfiltered streams.
+// Byte slices and strings are sent as they are; other data is encoded as
+// JSON. Event types must not contain line breaks. Clients whose buffer is
+// full are evicted and may resume with Last-Event-ID.
+func (b *EventBroker) Publish(topic, eventType string, data interface{}) (Event, error) {
+	if strings.ContainsAny(eventType, "\r\n") {
+		return Event{}, fmt.Errorf("event type %q contains a line break", eventType)
+	}
+
+	var payload []byte
+	switch d := data.(type) {
+	case []byte:
+		payload = d
+	case string:
+		payload = []byte(d)
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to encode event data: %w", err)
+		}
+		payload = encoded
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return Event{}, fmt.Errorf("event broker is closed")
+	}
+
+	b.seq++
+	event := Event{
+		ID:    b.eventID(b.seq),
+		Topic: topic,
+		Type:  eventType,
+		Data:  payload,
+	}
+
+	entry := sequencedEvent{seq: b.seq, event: event}
+	if len(b.replay) < b.cfg.ReplaySize {
+		b.replay = append(b.replay, entry)
+	} else {
+		b.replay[b.next] = entry
+		b.next = (b.next + 1) % b.cfg.ReplaySize
+	}
+
+	for c := range b.clients {
+		if c.topic != "" && c.topic != topic {
+			continue
+		}
+		select {
+		case c.events <- event:
+		default:
+			b.removeLocked(c)
+		}
+	}
+	return event, nil
+}
+
+// Stream creates a handler serving events as a text/event-stream. topicOf
+// selects the topic of a request, e.g. an order ID taken from the route; a
+// nil func or an empty topic receives every event.
+func (b *EventBroker) Stream(topicOf func(r *http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topic := ""
+		if topicOf != nil {
+			topic = topicOf(r)
+		}
+
+		client, backlog, gap, ok := b.subscribe(topic, r.Header.Get(HeaderLastEventID))
+		if !ok {
+			WriteError(w, r, apperrors.New(CodeUnavailable, "event stream is closed"))
+			return
+		}
+		defer b.unsubscribe(client)
+
+		h := w.Header()
+		h.Set("Content-Type", ContentTypeEventStream)
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		rc := http.NewResponseController(w)
+		var buf bytes.Buffer
+		if b.cfg.Retry > 0 {
+			fmt.Fprintf(&buf, "retry: %d\n\n", b.cfg.Retry.Milliseconds())
+		}
+		if gap {
+			writeEvent(&buf, Event{Type: EventReset, Data: []byte("{}")})
+		}
+		for _, event := range backlog {
+			writeEvent(&buf, event)
+		}
+		if err := b.flush(w, rc, &buf); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(b.cfg.Heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-client.gone:
+				return
+			case event := <-client.events:
+				writeEvent(&buf, event)
+				// Batch whatever else is already queued into a single write
+				for drained := false; !drained; {
+					select {
+					case event := <-client.events:
+						writeEvent(&buf, event)
+					default:
+						drained = true
+					}
+				}
+			case <-heartbeat.C:
+				buf.WriteString(": heartbeat\n\n")
+			}
+			if err := b.flush(w, rc, &buf); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// Clients returns the number of connected streams
+func (b *EventBroker) Clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
+
+// Close disconnects every stream and rejects further events
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for c := range b.clients {
+		b.removeLocked(c)
+	}
+}
+
+// subscribe registers a client and returns the events it missed since
+// lastEventID. gap reports that some of them are no longer available.
+func (b *EventBroker) subscribe(topic, lastEventID string) (*streamClient, []Event, bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, false, false
+	}
+
+	var backlog []Event
+	gap := false
+	if lastEventID != "" {
+		last, ok := b.parseEventID(lastEventID)
+		if !ok || last > b.seq {
+			// IDs of another broker instance cannot be resumed
+			gap = true
+		} else {
+			oldest := b.seq + 1
+			if len(b.replay) > 0 {
+				oldest = b.replay[b.next].seq
+			}
+			gap = last+1 < oldest
+
+			for i := 0; i < len(b.replay); i++ {
+				entry := b.replay[(b.next+i)%len(b.replay)]
+				if entry.seq > last && (topic == "" || entry.event.Topic == topic) {
+					backlog = append(backlog, entry.event)
+				}
+			}
+		}
+	}
+
+	client := &streamClient{
+		topic:  topic,
+		events: make(chan Event, b.cfg.BufferSize),
+		gone:   make(chan struct{}),
+	}
+	b.clients[client] = struct{}{}
+	return client, backlog, gap, true
+}
+
+// unsubscribe removes a disconnected client
+func (b *EventBroker) unsubscribe(c *streamClient) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.clients[c]; ok {
+		b.removeLocked(c)
+	}
+}
+
+// removeLocked disconnects a client; b.mu must be held
+func (b *EventBroker) removeLocked(c *streamClient) {
+	delete(b.clients, c)
+	close(c.gone)
+}
+
+// flush writes the buffered output within the write timeout
+func (b *EventBroker) flush(w http.ResponseWriter, rc *http.ResponseController, buf *bytes.Buffer) error {
+	// Streams outlive the server write timeout, so every write gets its own
+	// deadline; recorders without deadline support are written to as is
+	rc.SetWriteDeadline(time.Now().Add(b.cfg.WriteTimeout))
+	_, err := w.Write(buf.Bytes())
+	buf.Reset()
+	if err != nil {
+		return err
+	}
+	if err := rc.Flush(); err != nil && err != http.ErrNotSupported {
+		return err
+	}
+	return nil
+}
+
+// eventID formats the ID of the event at seq
+func (b *EventBroker) eventID(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventID returns the sequence of an event ID of this broker
+func (b *EventBroker) parseEventID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// writeEvent encodes an event in the text/event-stream format. Data is
+// split into lines at every CRLF, CR and LF, as clients do.
+func writeEvent(buf *bytes.Buffer, event Event) {
+	if event.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", event.ID)
+	}
+	if event.Type != "" {
+		fmt.Fprintf(buf, "event: %s\n", event.Type)
+	}
+	data := lineBreaks.Replace(string(event.Data))
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+}
+
+// lineBreaks normalizes the line breaks of event data to LF
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
@@ -0,0 +1,238 @@
+package server_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent represents a parsed server-sent event
+type sseEvent struct {
+	ID      string
+	Type    string
+	Data    string
+	Comment string
+}
+
+// sseStream reads events from a streaming response
+type sseStream struct {
+	resp   *http.Response
+	reader *bufio.Reader
+}
+
+func openStream(t *testing.T, url, lastEventID string) *sseStream {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", server.ContentTypeEventStream)
+	if lastEventID != "" {
+		req.Header.Set(server.HeaderLastEventID, lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return &sseStream{resp: resp, reader: bufio.NewReader(resp.Body)}
+}
+
+// next reads the next event or comment block
+func (s *sseStream) next(t *testing.T) sseEvent {
+	var event sseEvent
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			event.Data = strings.Join(data, "\n")
+			return event
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "":
+			event.Comment = value
+		case "id":
+			event.ID = value
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			event.Comment = "retry " + value
+		}
+	}
+}
+
+func newOrderEvents(t *testing.T, cfg server.StreamConfig) (*server.EventBroker, *httptest.Server) {
+	broker := server.NewEventBroker(cfg)
+	router := server.NewRouter()
+	router.Use(server.Timeout(50 * time.Millisecond))
+	router.Get("/events", broker.Stream(nil).ServeHTTP)
+	router.Get("/orders/{id}/events", broker.Stream(func(r *http.Request) string {
+		return server.Param(r, "id")
+	}).ServeHTTP)
+
+	ts := httptest.NewServer(router)
+	t.Cleanup(func() {
+		broker.Close()
+		ts.Close()
+	})
+	return broker, ts
+}
+
+func waitForClients(t *testing.T, broker *server.EventBroker, n int) {
+	assert.Eventually(t, func() bool { return broker.Clients() == n }, time.Second, 5*time.Millisecond)
+}
+
+func TestEventBrokerStream(t *testing.T) {
+	broker, ts := newOrderEvents(t, server.StreamConfig{Retry: 3 * time.Second})
+
+	order := openStream(t, ts.URL+"/orders/42/events", "")
+	all := openStream(t, ts.URL+"/events", "")
+	assert.Equal(t, http.StatusOK, order.resp.StatusCode)
+	assert.Equal(t, server.ContentTypeEventStream, order.resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", order.resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "retry 3000", order.next(t).Comment)
+	assert.Equal(t, "retry 3000", all.next(t).Comment)
+	waitForClients(t, broker, 2)
+
+	other, err := broker.Publish("7", "order.status", map[string]string{"status": "paid"})
+	require.NoError(t, err)
+	mine, err := broker.Publish("42", "order.status", map[string]string{"status": "shipped"})
+	require.NoError(t, err)
+	_, err = broker.Publish("42", "", "line one\nline two")
+	require.NoError(t, err)
+
+	assert.Equal(t, sseEvent{ID: mine.ID, Type: "order.status", Data: `{"status":"shipped"}`}, order.next(t))
+	assert.Equal(t, "line one\nline two", order.next(t).Data)
+
+	assert.Equal(t, other.ID, all.next(t).ID)
+	assert.Equal(t, mine.ID, all.next(t).ID)
+
+	// Streams outlive the request timeout
+	time.Sleep(100 * time.Millisecond)
+	_, err = broker.Publish("42", "order.status", "late")
+	require.NoError(t, err)
+	assert.Equal(t, "late", order.next(t).Data)
+}
+
+func TestEventBrokerLineBreaks(t *testing.T) {
+	broker, ts := newOrderEvents(t, server.StreamConfig{})
+	stream := openStream(t, ts.URL+"/events", "")
+	waitForClients(t, broker, 1)
+
+	_, err := broker.Publish("42", "order.status", "one\r\ntwo\rthree\nfour")
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree\nfour", stream.next(t).Data)
+
+	for _, eventType := range []string{"order\nstatus", "order\rstatus"} {
+		_, err = broker.Publish("42", eventType, "{}")
+		assert.Error(t, err)
+	}
+}
+
+func TestEventBrokerResume(t *testing.T) {
+	broker, ts := newOrderEvents(t, server.StreamConfig{ReplaySize: 3})
+
+	var ids []string
+	for _, topic := range []string{"1", "2", "1", "1"} {
+		event, err := broker.Publish(topic, "order.status", topic)
+		require.NoError(t, err)
+		ids = append(ids, event.ID)
+	}
+
+	// Events after the second one are still buffered
+	stream := openStream(t, ts.URL+"/orders/1/events", ids[1])
+	assert.Equal(t, ids[2], stream.next(t).ID)
+	assert.Equal(t, ids[3], stream.next(t).ID)
+	waitForClients(t, broker, 1)
+	latest, err := broker.Publish("1", "order.status", "1")
+	require.NoError(t, err)
+	assert.Equal(t, latest.ID, stream.next(t).ID)
+
+	// The first event after ids[0] has been dropped from the replay buffer
+	stream = openStream(t, ts.URL+"/events", ids[0])
+	assert.Equal(t, sseEvent{Type: server.EventReset, Data: "{}"}, stream.next(t))
+	assert.Equal(t, ids[2], stream.next(t).ID)
+
+	// IDs of another broker instance cannot be resumed
+	stream = openStream(t, ts.URL+"/events", "previous-17")
+	assert.Equal(t, server.EventReset, stream.next(t).Type)
+}
+
+func TestEventBrokerHeartbeat(t *testing.T) {
+	_, ts := newOrderEvents(t, server.StreamConfig{Heartbeat: 20 * time.Millisecond})
+
+	stream := openStream(t, ts.URL+"/events", "")
+	assert.Equal(t, "heartbeat", stream.next(t).Comment)
+	assert.Equal(t, "heartbeat", stream.next(t).Comment)
+}
+
+// blockingWriter is a response writer whose writes block until released
+type blockingWriter struct {
+	header  http.Header
+	release chan struct{}
+}
+
+func (w *blockingWriter) Header() http.Header { return w.header }
+
+func (w *blockingWriter) WriteHeader(status int) {}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestEventBrokerEvictsSlowClients(t *testing.T) {
+	broker := server.NewEventBroker(server.StreamConfig{BufferSize: 2})
+	defer broker.Close()
+
+	w := &blockingWriter{header: http.Header{}, release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		broker.Stream(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+		close(done)
+	}()
+	waitForClients(t, broker, 1)
+
+	// The stalled client absorbs its buffer, then is evicted
+	for i := 0; i < 3; i++ {
+		_, err := broker.Publish("1", "order.status", "paid")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 0, broker.Clients())
+
+	close(w.release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("evicted stream did not end")
+	}
+}
+
+func TestEventBrokerClose(t *testing.T) {
+	broker, ts := newOrderEvents(t, server.StreamConfig{})
+
+	stream := openStream(t, ts.URL+"/events", "")
+	waitForClients(t, broker, 1)
+	broker.Close()
+	assert.Equal(t, 0, broker.Clients())
+
+	// The stream ends without further events
+	_, err := stream.reader.ReadString('\n')
+	assert.Error(t, err)
+
+	_, err = broker.Publish("1", "order.status", "paid")
+	assert.Error(t, err)
+
+	resp, err := http.Get(ts.URL + "/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"order-system/pkg/platform/logger"
+)
+
+// defaultShutdownTimeout bounds graceful shutdown when none is configured
+const defaultShutdownTimeout = 30 * time.Second
+
+// Listener creates the network listener a server accepts connections on
+type Listener interface {
+	// Listen binds the listener
+	Listen() (net.Listener, error)
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithListener sets the listener the server binds, replacing the TCP
+// listener on config.HTTP.Port
+func WithListener(l Listener) Option {
+	return func(s *Server) {
+		s.listener = l
+	}
+}
+
+// WithLogger sets the logger used for lifecycle events
+func WithLogger(log logger.Logger) Option {
+	return func(s *Server) {
+		s.log = log
+	}
+}
+
+// WithWriteTimeout replaces config.HTTP.WriteTimeout for this server. Zero
+// disables the timeout.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.server.WriteTimeout = d
+	}
+}
+
+// WithSignals sets the signals that trigger graceful shutdown in Run.
+// The default is SIGINT and SIGTERM.
+func WithSignals(signals ...os.Signal) Option {
+	return func(s *Server) {
+		s.signals = signals
+	}
+}
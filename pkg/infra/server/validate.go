@@ -0,0 +1,267 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError represents a failed validation rule of a field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors represents all field errors of a value
+type ValidationErrors []FieldError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// patterns caches compiled regex rules
+var patterns sync.Map
+
+// Validate checks v against the rules in its `validate` struct tags and
+// returns all failures as ValidationErrors. Rules are comma-separated:
+//
+//	required    the value must not be zero (or nil, or empty)
+//	min=N       minimum value of numbers, length of strings, slices and maps
+//	max=N       maximum value of numbers, length of strings, slices and maps
+//	enum=a|b    the value must be one of the listed values
+//	regex=expr  strings must match expr; must be the last rule
+//
+// Rules other than required are skipped for nil pointers and empty strings,
+// slices and maps, but apply to zero numbers and false. Nested structs,
+// pointers and slices of structs are validated recursively and fields are
+// named by their JSON names. Invalid rules panic.
+func Validate(v interface{}) error {
+	var errs ValidationErrors
+	validateValue(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateValue validates the fields of structs found in v
+func validateValue(v reflect.Value, path string, errs *ValidationErrors) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if field.Anonymous && field.Tag.Get("json") == "" {
+				fieldPath = path
+			}
+
+			value := v.Field(i)
+			if tag := field.Tag.Get("validate"); tag != "" {
+				if !validateField(value, fieldPath, tag, errs) {
+					continue
+				}
+			}
+			validateValue(value, fieldPath, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), errs)
+		}
+	}
+}
+
+// validateField applies the rules of a tag to a field. It returns false if
+// the field is absent, so nested values should not be validated.
+func validateField(v reflect.Value, path, tag string, errs *ValidationErrors) bool {
+	fail := func(rule, format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, rule := range splitRules(tag) {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if isEmpty(v) {
+				fail(name, "is required")
+				return false
+			}
+			continue
+		}
+
+		// Other rules do not apply to absent optional values
+		if isAbsent(v) {
+			return false
+		}
+		value := v
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+
+		switch name {
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("server: invalid %s rule on %s: %q", name, path, arg))
+			}
+			size, ok := measure(value)
+			if !ok {
+				panic(fmt.Sprintf("server: %s rule on %s requires a number, string, slice or map", name, path))
+			}
+			if (name == "min" && size < limit) || (name == "max" && size > limit) {
+				fail(name, "%s", limitMessage(name, arg, value))
+			}
+		case "enum":
+			allowed := strings.Split(arg, "|")
+			actual := fmt.Sprint(value.Interface())
+			found := false
+			for _, a := range allowed {
+				if a == actual {
+					found = true
+					break
+				}
+			}
+			if !found {
+				fail(name, "must be one of %s", strings.Join(allowed, ", "))
+			}
+		case "regex":
+			if value.Kind() != reflect.String {
+				panic(fmt.Sprintf("server: regex rule on %s requires a string", path))
+			}
+			if !compilePattern(path, arg).MatchString(value.String()) {
+				fail(name, "must match %s", arg)
+			}
+		default:
+			panic(fmt.Sprintf("server: unknown validation rule %q on %s", name, path))
+		}
+	}
+	return true
+}
+
+// splitRules splits a tag into rules; a regex rule takes the rest of the tag
+func splitRules(tag string) []string {
+	var rules []string
+	for tag != "" {
+		if strings.HasPrefix(tag, "regex=") {
+			return append(rules, tag)
+		}
+		rule, rest, _ := strings.Cut(tag, ",")
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+		tag = strings.TrimSpace(rest)
+	}
+	return rules
+}
+
+// compilePattern returns the cached compiled regex of a rule
+func compilePattern(path, expr string) *regexp.Regexp {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		panic(fmt.Sprintf("server: invalid regex rule on %s: %v", path, err))
+	}
+	patterns.Store(expr, re)
+	return re
+}
+
+// isEmpty reports whether a value is absent for the required rule
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isAbsent reports whether an optional value was left out. Zero numbers and
+// false are present values, so that min=1 rejects 0.
+func isAbsent(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+// measure returns the value of a number or the length of a string, slice or map
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+// limitMessage describes a failed min or max rule
+func limitMessage(rule, arg string, v reflect.Value) string {
+	bound := "at least"
+	if rule == "max" {
+		bound = "at most"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must have %s %s characters", bound, arg)
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return fmt.Sprintf("must have %s %s elements", bound, arg)
+	default:
+		return fmt.Sprintf("must be %s %s", bound, arg)
+	}
+}
+
+// jsonName returns the JSON name of a struct field
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+
+	"order-system/pkg/infra/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderItem struct {
+	SKU      string `json:"sku" validate:"required,regex=^[A-Z]{3}-[0-9]+$"`
+	Quantity int    `json:"quantity" validate:"min=1,max=99"`
+}
+
+type address struct {
+	Country string `json:"country" validate:"required,enum=DE|FR|US"`
+}
+
+type createOrder struct {
+	CustomerID string      `json:"customerId" validate:"required"`
+	Currency   string      `json:"currency" validate:"enum=EUR|USD"`
+	Note       *string     `json:"note" validate:"max=5"`
+	Items      []orderItem `json:"items" validate:"required,max=3"`
+	Shipping   *address    `json:"shipping"`
+	internal   string
+}
+
+func fieldErrors(t *testing.T, err error) map[string]string {
+	var errs server.ValidationErrors
+	require.True(t, errors.As(err, &errs), "expected validation errors, got %v", err)
+
+	result := make(map[string]string)
+	for _, fe := range errs {
+		result[fe.Field] = fe.Rule + ": " + fe.Message
+	}
+	return result
+}
+
+func TestValidateValid(t *testing.T) {
+	note := "fast"
+	err := server.Validate(&createOrder{
+		CustomerID: "c-1",
+		Currency:   "EUR",
+		Note:       &note,
+		Items:      []orderItem{{SKU: "ABC-1", Quantity: 2}},
+		Shipping:   &address{Country: "DE"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	note := "too long"
+	err := server.Validate(&createOrder{
+		Currency: "GBP",
+		Note:     &note,
+		Items: []orderItem{
+			{SKU: "ABC-1", Quantity: 1},
+			{SKU: "bad", Quantity: 100},
+		},
+		Shipping: &address{},
+	})
+
+	assert.Equal(t, map[string]string{
+		"customerId":        "required: is required",
+		"currency":          "enum: must be one of EUR, USD",
+		"note":              "max: must have at most 5 characters",
+		"items[1].sku":      "regex: must match ^[A-Z]{3}-[0-9]+$",
+		"items[1].quantity": "max: must be at most 99",
+		"shipping.country":  "required: is required",
+	}, fieldErrors(t, err))
+}
+
+func TestValidateLengthAndOptionalValues(t *testing.T) {
+	err := server.Validate(createOrder{
+		CustomerID: "c-1",
+		Items: []orderItem{
+			{SKU: "ABC-1", Quantity: 1},
+			{SKU: "ABC-2", Quantity: 1},
+			{SKU: "ABC-3", Quantity: 1},
+			{SKU: "ABC-4", Quantity: 1},
+		},
+	})
+
+	errs := fieldErrors(t, err)
+	assert.Equal(t, "max: must have at most 3 elements", errs["items"])
+	assert.NotContains(t, errs, "note")
+	assert.NotContains(t, errs, "shipping.country")
+	assert.NotContains(t, errs, "currency")
+}
+
+func TestValidateZeroNumbers(t *testing.T) {
+	type priority struct {
+		Level int `json:"level" validate:"enum=1|2|3"`
+	}
+
+	err := server.Validate(&createOrder{
+		CustomerID: "c-1",
+		Items:      []orderItem{{SKU: "ABC-1", Quantity: 0}},
+	})
+	assert.Equal(t, map[string]string{
+		"items[0].quantity": "min: must be at least 1",
+	}, fieldErrors(t, err))
+
+	err = server.Validate(priority{})
+	assert.Equal(t, map[string]string{
+		"level": "enum: must be one of 1, 2, 3",
+	}, fieldErrors(t, err))
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	type invalid struct {
+		Count int `json:"count" validate:"min=abc"`
+	}
+	assert.Panics(t, func() { server.Validate(invalid{Count: 1}) })
+}
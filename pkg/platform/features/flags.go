@@ -0,0 +1,74 @@
+package features
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"order-system/pkg/infra/config"
+)
+
+// Flags represents a fixed set of named feature flags that can be toggled
+// at runtime
+type Flags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// New creates the flags declared in cfg.Features with their initial state
+func New(cfg *config.Config) *Flags {
+	return NewFlags(cfg.Features)
+}
+
+// NewFlags creates flags from their initial state
+func NewFlags(initial map[string]bool) *Flags {
+	flags := make(map[string]bool, len(initial))
+	for name, enabled := range initial {
+		flags[name] = enabled
+	}
+	return &Flags{flags: flags}
+}
+
+// Enabled reports whether a flag is enabled; unknown flags are disabled
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+// Set enables or disables a declared flag
+func (f *Flags) Set(name string, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.flags[name]; !ok {
+		return fmt.Errorf("unknown feature flag: %s", name)
+	}
+	f.flags[name] = enabled
+	return nil
+}
+
+// Names returns the sorted names of all flags
+func (f *Flags) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	names := make([]string, 0, len(f.flags))
+	for name := range f.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All returns a snapshot of every flag
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	result := make(map[string]bool, len(f.flags))
+	for name, enabled := range f.flags {
+		result[name] = enabled
+	}
+	return result
+}
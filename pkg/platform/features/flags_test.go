@@ -0,0 +1,32 @@
+package features_test
+
+import (
+	"testing"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/features"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *testing.T) {
+	cfg := &config.Config{Features: map[string]bool{"express_checkout": true, "new_pricing": false}}
+	flags := features.New(cfg)
+
+	assert.True(t, flags.Enabled("express_checkout"))
+	assert.False(t, flags.Enabled("new_pricing"))
+	assert.False(t, flags.Enabled("unknown"))
+	assert.Equal(t, []string{"express_checkout", "new_pricing"}, flags.Names())
+
+	assert.NoError(t, flags.Set("new_pricing", true))
+	assert.True(t, flags.Enabled("new_pricing"))
+	assert.Error(t, flags.Set("unknown", true))
+	assert.False(t, flags.Enabled("unknown"))
+
+	// Flags are copied so later config changes do not leak in
+	cfg.Features["express_checkout"] = false
+	snapshot := flags.All()
+	assert.Equal(t, map[string]bool{"express_checkout": true, "new_pricing": true}, snapshot)
+	snapshot["new_pricing"] = false
+	assert.True(t, flags.Enabled("new_pricing"))
+}
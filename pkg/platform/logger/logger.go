@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"order-system/pkg/infra/config"
@@ -16,14 +18,14 @@ import (
 type defaultLogger struct {
 	mu        sync.Mutex
 	out       io.Writer
-	level     Level
+	level     *atomic.Int32 // shared with derived loggers
 	component string
 	fields    []Field
 }
 
 // New creates a new logger
 func New(cfg *config.Config) (Logger, error) {
-	level, err := parseLevel(cfg.Logger.Level)
+	level, err := ParseLevel(cfg.Logger.Level)
 	if err != nil {
 		return nil, err
 	}
@@ -42,15 +44,17 @@ func New(cfg *config.Config) (Logger, error) {
 		out = file
 	}
 
-	return &defaultLogger{
+	l := &defaultLogger{
 		out:   out,
-		level: level,
-	}, nil
+		level: new(atomic.Int32),
+	}
+	l.SetLevel(level)
+	return l, nil
 }
 
-// parseLevel parses the log level string
-func parseLevel(level string) (Level, error) {
-	switch level {
+// ParseLevel parses the log level string
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(level) {
 	case "debug":
 		return Debug, nil
 	case "info":
@@ -66,32 +70,42 @@ func parseLevel(level string) (Level, error) {
 
 // Debug implements Logger.Debug
 func (l *defaultLogger) Debug(ctx context.Context, msg string, fields ...Field) {
-	if l.level <= Debug {
+	if l.Level() <= Debug {
 		l.log(ctx, Debug, msg, nil, fields...)
 	}
 }
 
 // Info implements Logger.Info
 func (l *defaultLogger) Info(ctx context.Context, msg string, fields ...Field) {
-	if l.level <= Info {
+	if l.Level() <= Info {
 		l.log(ctx, Info, msg, nil, fields...)
 	}
 }
 
 // Warn implements Logger.Warn
 func (l *defaultLogger) Warn(ctx context.Context, msg string, fields ...Field) {
-	if l.level <= Warn {
+	if l.Level() <= Warn {
 		l.log(ctx, Warn, msg, nil, fields...)
 	}
 }
 
 // Error implements Logger.Error
 func (l *defaultLogger) Error(ctx context.Context, msg string, err error, fields ...Field) {
-	if l.level <= Error {
+	if l.Level() <= Error {
 		l.log(ctx, Error, msg, err, fields...)
 	}
 }
 
+// Level implements LevelController.Level
+func (l *defaultLogger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// SetLevel implements LevelController.SetLevel
+func (l *defaultLogger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
 // WithComponent implements Logger.WithComponent
 func (l *defaultLogger) WithComponent(component string) Logger {
 	return &defaultLogger{
@@ -68,3 +68,12 @@ type Logger interface {
 	// WithFields returns a new logger with the given fields added
 	WithFields(fields ...Field) Logger
 }
+
+// LevelController is implemented by loggers whose level can change at
+// runtime. Loggers derived with WithComponent or WithFields share the level.
+type LevelController interface {
+	// Level returns the current level
+	Level() Level
+	// SetLevel changes the level
+	SetLevel(level Level)
+}
@@ -2,20 +2,65 @@ package metrics
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"order-system/pkg/infra/config"
 )
 
+// maxHistogramSamples bounds the recent observations kept per histogram
+// series for GetHistogram and Collect
+const maxHistogramSamples = 1024
+
+// DefaultBuckets are the histogram bucket upper bounds used unless a
+// histogram is registered with its own, suited to latencies in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
 // defaultCollector implements the Collector interface
 type defaultCollector struct {
 	mu           sync.RWMutex
-	counters     map[string]map[string]float64   // name -> labels -> value
-	gauges       map[string]map[string]float64   // name -> labels -> value
-	histograms   map[string]map[string][]float64 // name -> labels -> values
-	descriptions map[string]string               // name -> description
-	types        map[string]MetricType           // name -> type
+	counters     map[string]map[string]float64    // name -> labels -> value
+	gauges       map[string]map[string]float64    // name -> labels -> value
+	histograms   map[string]map[string]*histogram // name -> labels -> aggregate
+	buckets      map[string][]float64             // name -> bucket upper bounds
+	descriptions map[string]string                // name -> description
+	types        map[string]MetricType            // name -> type
+}
+
+// histogram aggregates the observations of a series into fixed buckets, so
+// that its size does not grow with the number of observations
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+	// recent is a ring of the latest observations
+	recent []float64
+	next   int
+}
+
+// observe adds an observation
+func (h *histogram) observe(value float64, buckets []float64) {
+	if i := sort.SearchFloat64s(buckets, value); i < len(buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += value
+
+	if len(h.recent) < maxHistogramSamples {
+		h.recent = append(h.recent, value)
+		return
+	}
+	h.recent[h.next] = value
+	h.next = (h.next + 1) % maxHistogramSamples
+}
+
+// samples returns the recent observations, oldest first
+func (h *histogram) samples() []float64 {
+	result := make([]float64, 0, len(h.recent))
+	result = append(result, h.recent[h.next:]...)
+	return append(result, h.recent[:h.next]...)
 }
 
 // New creates a new metrics collector
@@ -27,7 +72,8 @@ func New(cfg *config.Config) (Collector, error) {
 	return &defaultCollector{
 		counters:     make(map[string]map[string]float64),
 		gauges:       make(map[string]map[string]float64),
-		histograms:   make(map[string]map[string][]float64),
+		histograms:   make(map[string]map[string]*histogram),
+		buckets:      make(map[string][]float64),
 		descriptions: make(map[string]string),
 		types:        make(map[string]MetricType),
 	}, nil
@@ -35,11 +81,27 @@ func New(cfg *config.Config) (Collector, error) {
 
 // Register implements Collector.Register
 func (c *defaultCollector) Register(name string, metricType MetricType, description string) error {
+	return c.register(name, metricType, description, DefaultBuckets)
+}
+
+// RegisterHistogram implements Collector.RegisterHistogram
+func (c *defaultCollector) RegisterHistogram(name, description string, buckets []float64) error {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return c.register(name, Histogram, description, buckets)
+}
+
+// register adds a metric; buckets apply to histograms
+func (c *defaultCollector) register(name string, metricType MetricType, description string, buckets []float64) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if _, exists := c.types[name]; exists {
-		return fmt.Errorf("metric %s already registered", name)
+	if existing, exists := c.types[name]; exists {
+		if existing != metricType {
+			return fmt.Errorf("metric %s already registered as a %s", name, typeName(existing))
+		}
+		return fmt.Errorf("metric %s %w", name, ErrAlreadyRegistered)
 	}
 
 	c.types[name] = metricType
@@ -51,7 +113,10 @@ func (c *defaultCollector) Register(name string, metricType MetricType, descript
 	case Gauge:
 		c.gauges[name] = make(map[string]float64)
 	case Histogram:
-		c.histograms[name] = make(map[string][]float64)
+		c.histograms[name] = make(map[string]*histogram)
+		sorted := append([]float64(nil), buckets...)
+		sort.Float64s(sorted)
+		c.buckets[name] = sorted
 	}
 
 	return nil
@@ -126,9 +191,14 @@ func (c *defaultCollector) ObserveHistogram(name string, value float64, labels L
 
 	key := labelsToString(labels)
 	if _, exists := c.histograms[name]; !exists {
-		c.histograms[name] = make(map[string][]float64)
+		c.histograms[name] = make(map[string]*histogram)
 	}
-	c.histograms[name][key] = append(c.histograms[name][key], value)
+	h, exists := c.histograms[name][key]
+	if !exists {
+		h = &histogram{counts: make([]uint64, len(c.buckets[name]))}
+		c.histograms[name][key] = h
+	}
+	h.observe(value, c.buckets[name])
 }
 
 // GetHistogram implements Collector.GetHistogram
@@ -141,7 +211,40 @@ func (c *defaultCollector) GetHistogram(name string, labels Labels) []float64 {
 	}
 
 	key := labelsToString(labels)
-	return c.histograms[name][key]
+	h, exists := c.histograms[name][key]
+	if !exists {
+		return nil
+	}
+	return h.samples()
+}
+
+// CollectHistograms implements Collector.CollectHistograms
+func (c *defaultCollector) CollectHistograms() []HistogramSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var snapshots []HistogramSnapshot
+	for name, series := range c.histograms {
+		buckets := c.buckets[name]
+		for labelKey, h := range series {
+			counts := make([]uint64, len(h.counts))
+			var cumulative uint64
+			for i, n := range h.counts {
+				cumulative += n
+				counts[i] = cumulative
+			}
+			snapshots = append(snapshots, HistogramSnapshot{
+				Name:        name,
+				Labels:      stringToLabels(labelKey),
+				Description: c.descriptions[name],
+				Buckets:     append([]float64(nil), buckets...),
+				Counts:      counts,
+				Count:       h.count,
+				Sum:         h.sum,
+			})
+		}
+	}
+	return snapshots
 }
 
 // Collect implements Collector.Collect
@@ -182,8 +285,8 @@ func (c *defaultCollector) Collect() []Metric {
 
 	// Collect histograms
 	for name, values := range c.histograms {
-		for labelKey, histogram := range values {
-			for _, value := range histogram {
+		for labelKey, h := range values {
+			for _, value := range h.samples() {
 				metrics = append(metrics, Metric{
 					Name:        name,
 					Type:        Histogram,
@@ -199,26 +302,54 @@ func (c *defaultCollector) Collect() []Metric {
 	return metrics
 }
 
-// labelsToString converts Labels to a string key
+// labelsToString converts Labels to a string key. Separators inside names
+// and values are escaped so that stringToLabels can reverse the conversion.
 func labelsToString(labels Labels) string {
 	if len(labels) == 0 {
 		return ""
 	}
 
-	var result string
-	for k, v := range labels {
-		result += fmt.Sprintf("%s=%s;", k, v)
+	// Sort keys so that equal label sets always map to the same key
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
 	}
-	return result
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(keyEscaper.Replace(labels[k]))
+		b.WriteByte(';')
+	}
+	return b.String()
 }
 
+// keyEscaper escapes the separators of label keys
+var keyEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`)
+
 // stringToLabels converts a string key back to Labels
 func stringToLabels(s string) Labels {
-	if s == "" {
-		return Labels{}
-	}
-
 	labels := make(Labels)
-	// Simple implementation - in real code, you'd want to properly parse the string
+
+	var name string
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			part.WriteByte(s[i])
+		case c == '=':
+			name = part.String()
+			part.Reset()
+		case c == ';':
+			labels[name] = part.String()
+			name = ""
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
 	return labels
 }
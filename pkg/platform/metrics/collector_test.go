@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -51,6 +52,26 @@ func TestRegister(t *testing.T) {
 		err := collector.Register("test_counter", Counter, "duplicate counter")
 		assert.Error(t, err)
 	})
+
+	t.Run("register duplicate metric with another type", func(t *testing.T) {
+		err := collector.Register("test_counter", Counter, "duplicate counter")
+		assert.ErrorIs(t, err, ErrAlreadyRegistered)
+
+		err = collector.Register("test_counter", Gauge, "duplicate counter")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrAlreadyRegistered)
+	})
+
+	t.Run("ensure registered", func(t *testing.T) {
+		assert.NoError(t, EnsureRegistered(collector, "shared_counter", Counter, "shared counter"))
+		assert.NoError(t, EnsureRegistered(collector, "shared_counter", Counter, "shared counter"))
+		collector.IncrementCounter("shared_counter", 1, nil)
+		assert.Equal(t, float64(1), collector.GetCounter("shared_counter", nil))
+
+		assert.Error(t, EnsureRegistered(collector, "shared_counter", Gauge, "shared gauge"))
+		assert.NoError(t, EnsureHistogram(collector, "test_histogram", "test histogram", []float64{1}))
+		assert.Error(t, EnsureHistogram(collector, "shared_counter", "shared histogram", []float64{1}))
+	})
 }
 
 func TestSetGauge(t *testing.T) {
@@ -109,6 +130,49 @@ func TestObserveHistogram(t *testing.T) {
 	})
 }
 
+func TestHistogramAggregation(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, _ := New(cfg)
+
+	name := "aggregated_histogram"
+	labels := Labels{"label1": "value1"}
+	assert.NoError(t, collector.RegisterHistogram(name, "aggregated histogram", []float64{10, 1}))
+	assert.Error(t, collector.RegisterHistogram(name, "aggregated histogram", nil))
+
+	for i := 0; i < maxHistogramSamples+10; i++ {
+		collector.ObserveHistogram(name, float64(i%20), labels)
+	}
+
+	// Only the recent observations are kept as samples
+	values := collector.GetHistogram(name, labels)
+	assert.Len(t, values, maxHistogramSamples)
+	assert.Equal(t, float64((maxHistogramSamples+9)%20), values[len(values)-1])
+
+	snapshots := collector.CollectHistograms()
+	require.Len(t, snapshots, 1)
+	snapshot := snapshots[0]
+	assert.Equal(t, name, snapshot.Name)
+	assert.Equal(t, labels, snapshot.Labels)
+	assert.Equal(t, []float64{1, 10}, snapshot.Buckets)
+	assert.Equal(t, uint64(maxHistogramSamples+10), snapshot.Count)
+
+	var sum float64
+	var atMost1, atMost10 uint64
+	for i := 0; i < maxHistogramSamples+10; i++ {
+		v := float64(i % 20)
+		sum += v
+		if v <= 1 {
+			atMost1++
+		}
+		if v <= 10 {
+			atMost10++
+		}
+	}
+	assert.Equal(t, sum, snapshot.Sum)
+	assert.Equal(t, []uint64{atMost1, atMost10}, snapshot.Counts)
+}
+
 func TestCollect(t *testing.T) {
 	cfg := &config.Config{}
 	cfg.Metrics.Enabled = true
@@ -167,3 +231,22 @@ func TestLabelsConversion(t *testing.T) {
 		assert.Equal(t, labels, convertedLabels)
 	})
 }
+
+func TestMultipleLabels(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, _ := New(cfg)
+	collector.Register("test_requests", Counter, "test requests")
+
+	for i := 0; i < 10; i++ {
+		collector.IncrementCounter("test_requests", 1, Labels{"a": "1", "b": "2", "c": "3", "d": "4"})
+	}
+
+	assert.Equal(t, 10.0, collector.GetCounter("test_requests", Labels{"d": "4", "c": "3", "b": "2", "a": "1"}))
+}
+
+func TestLabelsRoundTrip(t *testing.T) {
+	labels := Labels{"route": "/a=b;c", `we\ird`: `x\;=y`, "empty": ""}
+	assert.Equal(t, labels, stringToLabels(labelsToString(labels)))
+	assert.NotEqual(t, labelsToString(Labels{"a": "1;b=2"}), labelsToString(Labels{"a": "1", "b": "2"}))
+}
@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeText is the content type of the Prometheus text exposition format
+const ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+
+// family represents the series of one metric
+type family struct {
+	name   string
+	typ    MetricType
+	help   string
+	series map[string]*series
+}
+
+// series represents the samples of one label set
+type series struct {
+	labels    Labels
+	value     float64
+	histogram *HistogramSnapshot
+}
+
+// Handler creates an HTTP handler serving every metric of the collector in
+// the Prometheus text format, gzip-compressed when the scraper accepts it.
+func Handler(collector Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		// Writing to a buffer cannot fail
+		_ = WriteText(&buf, collector)
+
+		w.Header().Set("Content-Type", ContentTypeText)
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+			w.Write(buf.Bytes())
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(buf.Bytes())
+		gz.Close()
+	})
+}
+
+// WriteText writes every metric of the collector in the Prometheus text
+// exposition format. Metrics are grouped by name with HELP and TYPE lines;
+// histograms are written from the buckets aggregated by the collector.
+func WriteText(w io.Writer, collector Collector) error {
+	var scalars []Metric
+	for _, m := range collector.Collect() {
+		if m.Type != Histogram {
+			scalars = append(scalars, m)
+		}
+	}
+	return writeText(w, scalars, collector.CollectHistograms())
+}
+
+// writeText renders counters, gauges and aggregated histograms
+func writeText(w io.Writer, scalars []Metric, histograms []HistogramSnapshot) error {
+	families := make(map[string]*family)
+	familyOf := func(name string, typ MetricType, help string) *family {
+		f, ok := families[name]
+		if !ok {
+			f = &family{
+				name:   name,
+				typ:    typ,
+				help:   help,
+				series: make(map[string]*series),
+			}
+			families[name] = f
+		}
+		return f
+	}
+
+	for _, m := range scalars {
+		f := familyOf(m.Name, m.Type, m.Description)
+		f.series[labelsToString(m.Labels)] = &series{labels: m.Labels, value: m.Value}
+	}
+	for i := range histograms {
+		h := &histograms[i]
+		f := familyOf(h.Name, Histogram, h.Description)
+		f.series[labelsToString(h.Labels)] = &series{labels: h.Labels, histogram: h}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		writeFamily(&b, families[name])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeFamily renders the HELP and TYPE lines and the samples of a metric
+func writeFamily(b *strings.Builder, f *family) {
+	if f.help != "" {
+		fmt.Fprintf(b, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, typeName(f.typ))
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if s.histogram == nil {
+			writeSample(b, f.name, s.labels, "", "", s.value)
+			continue
+		}
+
+		h := s.histogram
+		for i, upper := range h.Buckets {
+			writeSample(b, f.name+"_bucket", s.labels, "le", formatFloat(upper), float64(h.Counts[i]))
+		}
+		writeSample(b, f.name+"_bucket", s.labels, "le", "+Inf", float64(h.Count))
+		writeSample(b, f.name+"_sum", s.labels, "", "", h.Sum)
+		writeSample(b, f.name+"_count", s.labels, "", "", float64(h.Count))
+	}
+}
+
+// writeSample renders a sample line, appending the extra label if set
+func writeSample(b *strings.Builder, name string, labels Labels, extraName, extraValue string, value float64) {
+	b.WriteString(name)
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if extraName != "" {
+		keys = append(keys, extraName)
+	}
+
+	if len(keys) > 0 {
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			v := labels[k]
+			if k == extraName {
+				v = extraValue
+			}
+			fmt.Fprintf(b, `%s="%s"`, k, labelEscaper.Replace(v))
+		}
+		b.WriteByte('}')
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+// helpEscaper escapes HELP text as required by the text format
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// labelEscaper escapes label values as required by the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// typeName returns the text format name of a metric type
+func typeName(t MetricType) string {
+	switch t {
+	case Counter:
+		return "counter"
+	case Gauge:
+		return "gauge"
+	case Histogram:
+		return "histogram"
+	default:
+		return "untyped"
+	}
+}
+
+// formatFloat formats a sample value as understood by Prometheus
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err != nil || weight > 0
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"order-system/pkg/infra/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollector(t *testing.T) Collector {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, err := New(cfg)
+	require.NoError(t, err)
+	return collector
+}
+
+func TestWriteText(t *testing.T) {
+	collector := newTestCollector(t)
+	collector.Register("http_requests_total", Counter, "Total number of requests.\nBy route")
+	collector.Register("queue_depth", Gauge, "")
+	collector.RegisterHistogram("request_seconds", `Latency in \seconds`, []float64{1, 0.1})
+
+	collector.IncrementCounter("http_requests_total", 2, Labels{"route": "/orders/{id}", "method": "GET"})
+	collector.IncrementCounter("http_requests_total", 1, Labels{"route": `/a"b\c`, "method": "POST"})
+	collector.SetGauge("queue_depth", 7, nil)
+	collector.ObserveHistogram("request_seconds", 0.02, Labels{"route": "/orders"})
+	collector.ObserveHistogram("request_seconds", 0.3, Labels{"route": "/orders"})
+	collector.ObserveHistogram("request_seconds", 20, Labels{"route": "/orders"})
+
+	var b strings.Builder
+	require.NoError(t, WriteText(&b, collector))
+
+	expected := `# HELP http_requests_total Total number of requests.\nBy route
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/orders/{id}"} 2
+http_requests_total{method="POST",route="/a\"b\\c"} 1
+# TYPE queue_depth gauge
+queue_depth 7
+# HELP request_seconds Latency in \\seconds
+# TYPE request_seconds histogram
+request_seconds_bucket{route="/orders",le="0.1"} 1
+request_seconds_bucket{route="/orders",le="1"} 2
+request_seconds_bucket{route="/orders",le="+Inf"} 3
+request_seconds_sum{route="/orders"} 20.32
+request_seconds_count{route="/orders"} 3
+`
+	assert.Equal(t, expected, b.String())
+}
+
+func TestWriteTextDefaultBuckets(t *testing.T) {
+	collector := newTestCollector(t)
+	require.NoError(t, collector.Register("latency", Histogram, ""))
+	collector.ObserveHistogram("latency", 0.007, nil)
+
+	var b strings.Builder
+	require.NoError(t, WriteText(&b, collector))
+
+	assert.Contains(t, b.String(), "latency_bucket{le=\"0.005\"} 0\n")
+	assert.Contains(t, b.String(), "latency_bucket{le=\"0.01\"} 1\n")
+	assert.Contains(t, b.String(), "latency_bucket{le=\"10\"} 1\n")
+	assert.Contains(t, b.String(), "latency_count 1\n")
+}
+
+func TestHandlerAggregatedHistograms(t *testing.T) {
+	collector := newTestCollector(t)
+	require.NoError(t, collector.RegisterHistogram("request_seconds", "Latency", []float64{1, 0.1}))
+	for i := 0; i < 3000; i++ {
+		collector.ObserveHistogram("request_seconds", 0.0625, Labels{"route": "/orders"})
+	}
+	collector.ObserveHistogram("request_seconds", 20, Labels{"route": "/orders"})
+
+	rec := httptest.NewRecorder()
+	Handler(collector).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Observations beyond the recent samples are still counted
+	expected := `# HELP request_seconds Latency
+# TYPE request_seconds histogram
+request_seconds_bucket{route="/orders",le="0.1"} 3000
+request_seconds_bucket{route="/orders",le="1"} 3000
+request_seconds_bucket{route="/orders",le="+Inf"} 3001
+request_seconds_sum{route="/orders"} 207.5
+request_seconds_count{route="/orders"} 3001
+`
+	assert.Equal(t, expected, rec.Body.String())
+}
+
+func TestHandler(t *testing.T) {
+	collector := newTestCollector(t)
+	collector.Register("orders_total", Counter, "Total number of orders")
+	collector.IncrementCounter("orders_total", 3, Labels{"status": "paid"})
+	expected := "# HELP orders_total Total number of orders\n# TYPE orders_total counter\norders_total{status=\"paid\"} 3\n"
+
+	t.Run("plain", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Handler(collector).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ContentTypeText, rec.Header().Get("Content-Type"))
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, expected, rec.Body.String())
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+		rec := httptest.NewRecorder()
+		Handler(collector).ServeHTTP(rec, req)
+
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		gz, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(body))
+	})
+
+	t.Run("gzip refused", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip;q=0")
+		rec := httptest.NewRecorder()
+		Handler(collector).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, expected, rec.Body.String())
+	})
+}
@@ -1,6 +1,9 @@
 package metrics
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // MetricType represents the type of metric
 type MetricType int
@@ -27,7 +30,21 @@ type Metric struct {
 	Timestamp   time.Time
 }
 
-// Collector defines the metrics collection interface
+// HistogramSnapshot represents the aggregated observations of a histogram
+// series. Counts holds the cumulative count of each bucket upper bound.
+type HistogramSnapshot struct {
+	Name        string
+	Labels      Labels
+	Description string
+	Buckets     []float64
+	Counts      []uint64
+	Count       uint64
+	Sum         float64
+}
+
+// Collector defines the metrics collection interface. Histograms aggregate
+// every observation into buckets, a sum and a count, available from
+// CollectHistograms; only the most recent observations are kept as samples.
 type Collector interface {
 	// Counter operations
 	IncrementCounter(name string, value float64, labels Labels)
@@ -39,9 +56,42 @@ type Collector interface {
 
 	// Histogram operations
 	ObserveHistogram(name string, value float64, labels Labels)
+	// GetHistogram returns the most recent observations of a series, at
+	// most 1024, oldest first
 	GetHistogram(name string, labels Labels) []float64
+	CollectHistograms() []HistogramSnapshot
 
 	// General operations
 	Register(name string, metricType MetricType, description string) error
+	// RegisterHistogram registers a histogram with its bucket upper bounds;
+	// Register uses DefaultBuckets
+	RegisterHistogram(name, description string, buckets []float64) error
+	// Collect returns the current value of every counter and gauge series
+	// and, for histograms, the observations returned by GetHistogram
 	Collect() []Metric
 }
+
+// ErrAlreadyRegistered is returned when a metric is registered again with
+// the same type
+var ErrAlreadyRegistered = errors.New("already registered")
+
+// EnsureRegistered registers a metric shared by several components, such as
+// the HTTP clients or middlewares of a service. A metric already registered
+// with the same type is left as it is; a different type is an error.
+func EnsureRegistered(collector Collector, name string, metricType MetricType, description string) error {
+	return ignoreRegistered(collector.Register(name, metricType, description))
+}
+
+// EnsureHistogram is EnsureRegistered for a histogram with its own bucket
+// upper bounds. The buckets of the first registration are kept.
+func EnsureHistogram(collector Collector, name, description string, buckets []float64) error {
+	return ignoreRegistered(collector.RegisterHistogram(name, description, buckets))
+}
+
+// ignoreRegistered drops ErrAlreadyRegistered
+func ignoreRegistered(err error) error {
+	if errors.Is(err, ErrAlreadyRegistered) {
+		return nil
+	}
+	return err
+}